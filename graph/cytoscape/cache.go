@@ -0,0 +1,71 @@
+package cytoscape
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a small server-side LRU of prior Elements, keyed by the query signature
+// (e.g. namespace+graphType+groupBy+appenders) so that a delta request can be diffed
+// against the last full graph computed for the same query. It exists to back the
+// delta graph endpoint; callers with a cold cache should fall back to a full payload.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key    string
+	config Config
+}
+
+// NewCache returns a Cache holding at most capacity entries.
+func NewCache(capacity int) *Cache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Cache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the Config previously stored under key, if any ("cache cold" otherwise).
+func (c *Cache) Get(key string) (Config, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return Config{}, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).config, true
+}
+
+// Set stores config under key, evicting the least recently used entry if the cache
+// is at capacity.
+func (c *Cache) Set(key string, config Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*cacheEntry).config = config
+		return
+	}
+
+	el := c.ll.PushFront(&cacheEntry{key: key, config: config})
+	c.items[key] = el
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*cacheEntry).key)
+		}
+	}
+}
@@ -8,15 +8,16 @@
 // Demos:       http://js.cytoscape.org/#demos
 //
 // Algorithm: Process the graph structure adding nodes and edges, decorating each
-//            with information provided.  An optional second pass generates compound
-//            nodes for version grouping.
 //
+//	with information provided.  An optional second pass generates compound
+//	nodes for namespace, workload and/or version grouping.
 package cytoscape
 
 import (
 	"crypto/md5"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/kiali/kiali/graph"
 	"github.com/kiali/kiali/graph/options"
@@ -28,31 +29,34 @@ type NodeData struct {
 	Parent string `json:"parent,omitempty"` // Compound Node parent ID
 
 	// App Fields (not required by Cytoscape)
-	NodeType        string          `json:"nodeType"`
-	Namespace       string          `json:"namespace"`
-	Workload        string          `json:"workload,omitempty"`
-	App             string          `json:"app,omitempty"`
-	Version         string          `json:"version,omitempty"`
-	Service         string          `json:"service,omitempty"`         // requested service for NodeTypeService
-	DestServices    map[string]bool `json:"destServices,omitempty"`    // requested services for [dest] node
-	Rate            string          `json:"rate,omitempty"`            // edge aggregate
-	Rate3xx         string          `json:"rate3XX,omitempty"`         // edge aggregate
-	Rate4xx         string          `json:"rate4XX,omitempty"`         // edge aggregate
-	Rate5xx         string          `json:"rate5XX,omitempty"`         // edge aggregate
-	RateOut         string          `json:"rateOut,omitempty"`         // edge aggregate
-	RateTcpSent     string          `json:"rateTcpSent,omitempty"`     // edge aggregate
-	RateTcpSentOut  string          `json:"rateTcpSentOut,omitempty"`  // edge aggregate
-	HasCB           bool            `json:"hasCB,omitempty"`           // true (has circuit breaker) | false
-	HasMissingSC    bool            `json:"hasMissingSC,omitempty"`    // true (has missing sidecar) | false
-	HasVS           bool            `json:"hasVS,omitempty"`           // true (has route rule) | false
-	IsDead          bool            `json:"isDead,omitempty"`          // true (has no pods) | false
-	IsEgress        bool            `json:"isEgress,omitempty"`        // true | false
-	IsGroup         string          `json:"isGroup,omitempty"`         // set to the grouping type, current values: [ 'version' ]
-	IsInaccessible  bool            `json:"isInaccessible,omitempty"`  // true if the node exists in an inaccessible namespace
-	IsMisconfigured string          `json:"isMisconfigured,omitempty"` // set to misconfiguration list, current values: [ 'labels' ]
-	IsOutside       bool            `json:"isOutside,omitempty"`       // true | false
-	IsRoot          bool            `json:"isRoot,omitempty"`          // true | false
-	IsUnused        bool            `json:"isUnused,omitempty"`        // true | false
+	NodeType        string               `json:"nodeType"`
+	Namespace       string               `json:"namespace"`
+	Workload        string               `json:"workload,omitempty"`
+	App             string               `json:"app,omitempty"`
+	Version         string               `json:"version,omitempty"`
+	Service         string               `json:"service,omitempty"`         // requested service for NodeTypeService
+	DestServices    map[string]bool      `json:"destServices,omitempty"`    // requested services for [dest] node
+	Rate            string               `json:"rate,omitempty"`            // edge aggregate
+	Rate3xx         string               `json:"rate3XX,omitempty"`         // edge aggregate
+	Rate4xx         string               `json:"rate4XX,omitempty"`         // edge aggregate
+	Rate5xx         string               `json:"rate5XX,omitempty"`         // edge aggregate
+	RateOut         string               `json:"rateOut,omitempty"`         // edge aggregate
+	RateTcpSent     string               `json:"rateTcpSent,omitempty"`     // edge aggregate
+	RateTcpSentOut  string               `json:"rateTcpSentOut,omitempty"`  // edge aggregate
+	HasCB           bool                 `json:"hasCB,omitempty"`           // true (has circuit breaker) | false
+	HasMissingSC    bool                 `json:"hasMissingSC,omitempty"`    // true (has missing sidecar) | false
+	HasVS           bool                 `json:"hasVS,omitempty"`           // true (has route rule) | false
+	IsDead          bool                 `json:"isDead,omitempty"`          // true (has no pods) | false
+	IsEgress        bool                 `json:"isEgress,omitempty"`        // true | false
+	IsGroup         string               `json:"isGroup,omitempty"`         // set to the grouping type, current values: [ 'namespace', 'version', 'workload' ]
+	IsInaccessible  bool                 `json:"isInaccessible,omitempty"`  // true if the node exists in an inaccessible namespace
+	IsMisconfigured string               `json:"isMisconfigured,omitempty"` // set to misconfiguration list, current values: [ 'labels' ]
+	IsOutside       bool                 `json:"isOutside,omitempty"`       // true | false
+	IsRoot          bool                 `json:"isRoot,omitempty"`          // true | false
+	IsUnused        bool                 `json:"isUnused,omitempty"`        // true | false
+	Layer           int                  `json:"layer"`                     // topological layer assigned by graph.Layer
+	LayerOrder      int                  `json:"layerOrder"`                // stable secondary ordering within Layer
+	Readiness       *graph.NodeReadiness `json:"readiness,omitempty"`       // readiness verdict for the node's backing object(s), nil if never resolved
 }
 
 type EdgeData struct {
@@ -62,16 +66,17 @@ type EdgeData struct {
 	Target string `json:"target"` // child node ID
 
 	// App Fields (not required by Cytoscape)
-	Rate         string `json:"rate,omitempty"`
-	Rate3xx      string `json:"rate3XX,omitempty"`
-	Rate4xx      string `json:"rate4XX,omitempty"`
-	Rate5xx      string `json:"rate5XX,omitempty"`
-	PercentErr   string `json:"percentErr,omitempty"`
-	PercentRate  string `json:"percentRate,omitempty"` // percent of total parent requests
-	ResponseTime string `json:"responseTime,omitempty"`
-	IsMTLS       bool   `json:"isMTLS,omitempty"`   // true (mutual TLS connection) | false
-	IsUnused     bool   `json:"isUnused,omitempty"` // true | false
-	TcpSentRate  string `json:"tcpSentRate,omitempty"`
+	Rate                  string            `json:"rate,omitempty"`
+	Rate3xx               string            `json:"rate3XX,omitempty"`
+	Rate4xx               string            `json:"rate4XX,omitempty"`
+	Rate5xx               string            `json:"rate5XX,omitempty"`
+	PercentErr            string            `json:"percentErr,omitempty"`
+	PercentRate           string            `json:"percentRate,omitempty"` // percent of total parent requests
+	ResponseTime          string            `json:"responseTime,omitempty"`
+	ResponseTimeQuantiles map[string]string `json:"responseTimeQuantiles,omitempty"` // e.g. {"0.5":"12.30","0.95":"44.10","0.99":"80.00"}
+	IsMTLS                bool              `json:"isMTLS,omitempty"`                // true (mutual TLS connection) | false
+	IsUnused              bool              `json:"isUnused,omitempty"`              // true | false
+	TcpSentRate           string            `json:"tcpSentRate,omitempty"`
 }
 
 type NodeWrapper struct {
@@ -105,12 +110,27 @@ func NewConfig(trafficMap graph.TrafficMap, o options.VendorOptions) (result Con
 	nodes := []*NodeWrapper{}
 	edges := []*EdgeWrapper{}
 
+	// Assign a topological layer to every node before rendering so the UI can lay the
+	// graph out deterministically instead of relying on force-directed placement. The
+	// strategy/pruning are caller-controlled via o.Layering (layerOrder=/
+	// showWholeGraph=/roots= query params - see options.NewOptions).
+	layering := o.Layering
+	if layering.Order == "" {
+		// o.Layering was never set (e.g. a VendorOptions built without going through
+		// options.NewOptions) - fall back to the same default NewOptions itself uses:
+		// topological order over the whole graph, nothing pruned.
+		layering.Order = graph.TopoOrder
+		layering.ShowWholeGraph = true
+	}
+	graph.Layer(trafficMap, layering)
+
 	buildConfig(trafficMap, &nodes, &edges, o)
 
-	// Add compound nodes that group together different versions of the same node
-	if o.GraphType == graph.GraphTypeVersionedApp && o.GroupBy == options.GroupByVersion {
-		groupByVersion(&nodes)
-	}
+	// Add compound nodes for each grouping mode requested via GroupBy. Modes can be
+	// stacked with a CSV, e.g. "groupBy=namespace,version" nests namespace -> app -> version
+	// boxes; applyGrouping runs innermost-first so earlier (outer) modes box the later
+	// modes' output.
+	applyGrouping(&nodes, o)
 
 	// sort nodes and edges for better json presentation (and predictable testing)
 	// kiali-1258 compound/isGroup/parent nodes must come before the child references
@@ -118,8 +138,8 @@ func NewConfig(trafficMap graph.TrafficMap, o options.VendorOptions) (result Con
 		switch {
 		case nodes[i].Data.Namespace != nodes[j].Data.Namespace:
 			return nodes[i].Data.Namespace < nodes[j].Data.Namespace
-		case nodes[i].Data.IsGroup != nodes[j].Data.IsGroup:
-			return nodes[i].Data.IsGroup > nodes[j].Data.IsGroup
+		case groupRank(nodes[i].Data.IsGroup) != groupRank(nodes[j].Data.IsGroup):
+			return groupRank(nodes[i].Data.IsGroup) < groupRank(nodes[j].Data.IsGroup)
 		case nodes[i].Data.App != nodes[j].Data.App:
 			return nodes[i].Data.App < nodes[j].Data.App
 		case nodes[i].Data.Version != nodes[j].Data.Version:
@@ -221,6 +241,26 @@ func buildConfig(trafficMap graph.TrafficMap, nodes *[]*NodeWrapper, edges *[]*E
 			nd.IsEgress = val.(bool)
 		}
 
+		// topological layer assigned by the graph.Layer call in NewConfig
+		if val, ok := n.Metadata["layer"]; ok {
+			if layer, ok := val.(int); ok {
+				nd.Layer = layer
+			}
+		}
+		if val, ok := n.Metadata["layerOrder"]; ok {
+			if layerOrder, ok := val.(int); ok {
+				nd.LayerOrder = layerOrder
+			}
+		}
+
+		// readiness verdict set by graph.SetNodeReadiness, so the UI can badge an
+		// unhealthy node distinctly from one that simply has no traffic
+		if val, ok := n.Metadata["readiness"]; ok {
+			if readiness, ok := val.(graph.NodeReadiness); ok {
+				nd.Readiness = &readiness
+			}
+		}
+
 		nw := NodeWrapper{
 			Data: nd,
 		}
@@ -320,10 +360,13 @@ func addEdgeTelemetry(ed *EdgeData, e *graph.Edge, o options.VendorOptions) {
 		}
 
 		if val, ok := e.Metadata["responseTime"]; ok {
-			responseTime := val.(float64)
-			ed.ResponseTime = fmt.Sprintf("%.2f", responseTime)
+			if responseTime, ok := val.(float64); ok {
+				ed.ResponseTime = fmt.Sprintf("%.2f", responseTime)
+			}
 		}
 
+		addEdgeResponseTimeQuantiles(ed, e, o)
+
 		percentRate := rate / getRate(e.Source.Metadata, "rateOut") * 100.0
 		if percentRate < 100.0 {
 			ed.PercentRate = fmt.Sprintf("%.2f", percentRate)
@@ -344,50 +387,128 @@ func addEdgeTelemetry(ed *EdgeData, e *graph.Edge, o options.VendorOptions) {
 	}
 }
 
-// groupByVersion adds compound nodes to group multiple versions of the same app
-func groupByVersion(nodes *[]*NodeWrapper) {
-	grouped := make(map[string][]*NodeData)
-
-	for _, nw := range *nodes {
-		if nw.Data.NodeType == graph.NodeTypeApp {
-			k := fmt.Sprintf("box_%s_%s", nw.Data.Namespace, nw.Data.App)
-			grouped[k] = append(grouped[k], nw.Data)
+// responseTimeQuantileMetadataKey is the Metadata key prefix the telemetry pipeline
+// uses to store a per-quantile responseTime, populated via histogram_quantile over
+// istio_request_duration_seconds_bucket (e.g. "responseTime:0.95").
+const responseTimeQuantileMetadataKey = "responseTime:"
+
+// addEdgeResponseTimeQuantiles copies the quantiles requested via o.Quantiles from
+// e.Metadata onto ed, skipping any quantile the telemetry pipeline didn't populate.
+// Populating e.Metadata["responseTime:"+q] is the ResponseTimeAppender's job (it runs
+// histogram_quantile over istio_request_duration_seconds_bucket via the Prometheus
+// client, neither of which is part of this trimmed tree) - an edge with no quantiles
+// in Metadata simply renders with an empty ResponseTimeQuantiles, same as rate==0.
+func addEdgeResponseTimeQuantiles(ed *EdgeData, e *graph.Edge, o options.VendorOptions) {
+	for _, q := range o.Quantiles {
+		val, ok := e.Metadata[responseTimeQuantileMetadataKey+q]
+		if !ok {
+			continue
+		}
+		responseTime, ok := val.(float64)
+		if !ok {
+			continue
+		}
+		if ed.ResponseTimeQuantiles == nil {
+			ed.ResponseTimeQuantiles = make(map[string]string)
 		}
+		ed.ResponseTimeQuantiles[q] = fmt.Sprintf("%.2f", responseTime)
 	}
+}
 
-	for k, members := range grouped {
-		if len(members) > 1 {
-			// create the compound grouping all versions of the app
-			nodeId := nodeHash(k)
-			nd := NodeData{
-				Id:        nodeId,
-				NodeType:  graph.NodeTypeApp,
-				Namespace: members[0].Namespace,
-				App:       members[0].App,
-				Version:   "",
-				IsGroup:   options.GroupByVersion,
-			}
+// groupRank orders IsGroup values so that outer boxes (e.g. namespace) are emitted
+// before the boxes/nodes they parent, satisfying the kiali-1258 "parents precede
+// children" invariant even when grouping modes are stacked.
+func groupRank(isGroup string) int {
+	switch isGroup {
+	case options.GroupByNamespace:
+		return 0
+	case options.GroupByVersion, options.GroupByWorkload:
+		return 1
+	default:
+		return 2
+	}
+}
 
-			nw := NodeWrapper{
-				Data: &nd,
+// applyGrouping runs each grouping mode requested via o.GroupBy (a CSV, e.g.
+// "namespace,version"). Modes are applied innermost-first so that an outer mode
+// (namespace) parents the boxes the inner mode (version) already produced, yielding
+// the nested namespace -> app -> version boxes the stacked-grouping UX expects.
+func applyGrouping(nodes *[]*NodeWrapper, o options.VendorOptions) {
+	modes := strings.Split(o.GroupBy, ",")
+	for i := len(modes) - 1; i >= 0; i-- {
+		switch strings.TrimSpace(modes[i]) {
+		case options.GroupByVersion:
+			if o.GraphType == graph.GraphTypeVersionedApp {
+				groupNodes(nodes, options.GroupByVersion, func(nd *NodeData) (string, bool) {
+					if nd.NodeType != graph.NodeTypeApp || nd.Parent != "" {
+						return "", false
+					}
+					return fmt.Sprintf("box_version_%s_%s", nd.Namespace, nd.App), true
+				}, func(members []*NodeData) NodeData {
+					return NodeData{NodeType: graph.NodeTypeApp, Namespace: members[0].Namespace, App: members[0].App}
+				})
 			}
+		case options.GroupByWorkload:
+			groupNodes(nodes, options.GroupByWorkload, func(nd *NodeData) (string, bool) {
+				if nd.NodeType != graph.NodeTypeWorkload || nd.Parent != "" {
+					return "", false
+				}
+				return fmt.Sprintf("box_workload_%s_%s", nd.Namespace, nd.Workload), true
+			}, func(members []*NodeData) NodeData {
+				return NodeData{NodeType: graph.NodeTypeWorkload, Namespace: members[0].Namespace, Workload: members[0].Workload}
+			})
+		case options.GroupByNamespace:
+			groupNodes(nodes, options.GroupByNamespace, func(nd *NodeData) (string, bool) {
+				if nd.Parent != "" {
+					return "", false
+				}
+				return fmt.Sprintf("box_namespace_%s", nd.Namespace), true
+			}, func(members []*NodeData) NodeData {
+				return NodeData{Namespace: members[0].Namespace}
+			})
+		}
+	}
+}
 
-			// assign each app version node to the compound parent
-			nd.HasMissingSC = false // TODO: this is probably unecessarily noisy
-			nd.IsInaccessible = false
-			nd.IsOutside = false
+// groupNodes is the shared compound-node builder for every grouping mode: it buckets
+// the current top-level (unparented) members of *nodes by keyFunc, and for any bucket
+// with more than one member, creates a parent box (built by newParent) carrying the
+// rolled-up HasMissingSC/IsInaccessible/IsOutside flags.
+func groupNodes(nodes *[]*NodeWrapper, isGroup string, keyFunc func(*NodeData) (string, bool), newParent func([]*NodeData) NodeData) {
+	grouped := make(map[string][]*NodeData)
+	keyOrder := []string{}
 
-			for _, n := range members {
-				n.Parent = nodeId
+	for _, nw := range *nodes {
+		k, ok := keyFunc(nw.Data)
+		if !ok {
+			continue
+		}
+		if _, seen := grouped[k]; !seen {
+			keyOrder = append(keyOrder, k)
+		}
+		grouped[k] = append(grouped[k], nw.Data)
+	}
 
-				// copy some member attributes to to the compound node (aka app box)
-				nd.HasMissingSC = nd.HasMissingSC || n.HasMissingSC
-				nd.IsInaccessible = nd.IsInaccessible || n.IsInaccessible
-				nd.IsOutside = nd.IsOutside || n.IsOutside
-			}
+	for _, k := range keyOrder {
+		members := grouped[k]
+		if len(members) < 2 {
+			continue
+		}
+
+		nodeId := nodeHash(k)
+		nd := newParent(members)
+		nd.Id = nodeId
+		nd.IsGroup = isGroup
 
-			// add the compound node to the list of nodes
-			*nodes = append(*nodes, &nw)
+		for _, n := range members {
+			n.Parent = nodeId
+
+			// copy some member attributes to the compound node (aka box)
+			nd.HasMissingSC = nd.HasMissingSC || n.HasMissingSC
+			nd.IsInaccessible = nd.IsInaccessible || n.IsInaccessible
+			nd.IsOutside = nd.IsOutside || n.IsOutside
 		}
+
+		*nodes = append(*nodes, &NodeWrapper{Data: &nd})
 	}
 }
@@ -0,0 +1,109 @@
+package cytoscape
+
+import (
+	"crypto/md5"
+	"fmt"
+)
+
+// ConfigDelta is returned by the delta graph endpoint instead of a full Config: it
+// carries only what changed against the client's previously-seen Config, so that
+// topology-only consumers can skip rate-only updates and large meshes don't force a
+// full payload on every poll.
+type ConfigDelta struct {
+	Timestamp      int64          `json:"timestamp"`
+	GraphType      string         `json:"graphType"`
+	AddedNodes     []*NodeWrapper `json:"addedNodes,omitempty"`
+	RemovedNodeIds []string       `json:"removedNodeIds,omitempty"`
+	ChangedNodes   []*NodeWrapper `json:"changedNodes,omitempty"`
+	AddedEdges     []*EdgeWrapper `json:"addedEdges,omitempty"`
+	RemovedEdgeIds []string       `json:"removedEdgeIds,omitempty"`
+	ChangedEdges   []*EdgeWrapper `json:"changedEdges,omitempty"`
+}
+
+// NewConfigDelta computes the delta of curr against prev. Node/edge identity is the
+// (already stable) cytoscape Id (see nodeHash/edgeHash); "changed" is decided by
+// hashing each node/edge's telemetry fields, so a node whose topology is unchanged
+// but whose rate moved is reported as changed, not added/removed.
+func NewConfigDelta(prev, curr Config) ConfigDelta {
+	delta := ConfigDelta{
+		Timestamp: curr.Timestamp,
+		GraphType: curr.GraphType,
+	}
+
+	prevNodes := indexNodes(prev.Elements.Nodes)
+	for _, nw := range curr.Elements.Nodes {
+		prevNw, existed := prevNodes[nw.Data.Id]
+		switch {
+		case !existed:
+			delta.AddedNodes = append(delta.AddedNodes, nw)
+		case telemetryHash(nw.Data) != telemetryHash(prevNw.Data):
+			delta.ChangedNodes = append(delta.ChangedNodes, nw)
+		}
+		delete(prevNodes, nw.Data.Id)
+	}
+	for id := range prevNodes {
+		delta.RemovedNodeIds = append(delta.RemovedNodeIds, id)
+	}
+
+	prevEdges := indexEdges(prev.Elements.Edges)
+	for _, ew := range curr.Elements.Edges {
+		prevEw, existed := prevEdges[ew.Data.Id]
+		switch {
+		case !existed:
+			delta.AddedEdges = append(delta.AddedEdges, ew)
+		case edgeTelemetryHash(ew.Data) != edgeTelemetryHash(prevEw.Data):
+			delta.ChangedEdges = append(delta.ChangedEdges, ew)
+		}
+		delete(prevEdges, ew.Data.Id)
+	}
+	for id := range prevEdges {
+		delta.RemovedEdgeIds = append(delta.RemovedEdgeIds, id)
+	}
+
+	return delta
+}
+
+func indexNodes(nodes []*NodeWrapper) map[string]*NodeWrapper {
+	idx := make(map[string]*NodeWrapper, len(nodes))
+	for _, nw := range nodes {
+		idx[nw.Data.Id] = nw
+	}
+	return idx
+}
+
+func indexEdges(edges []*EdgeWrapper) map[string]*EdgeWrapper {
+	idx := make(map[string]*EdgeWrapper, len(edges))
+	for _, ew := range edges {
+		idx[ew.Data.Id] = ew
+	}
+	return idx
+}
+
+// telemetryHash hashes the telemetry-derived (non-identity, non-structural) fields of
+// a node, so topology-stable fields (Id, Parent, NodeType, namespace/app/...) don't
+// cause false "changed" reports.
+func telemetryHash(nd *NodeData) string {
+	// Readiness is resolved from live cluster state (Deployment/Pod/etc conditions), so
+	// it can flip between polls same as any rate; hash Ready+Reason only, not the whole
+	// struct - LastTransition is restamped to time.Now() on every SetNodeReadiness call
+	// regardless of whether the verdict actually changed, so including it would make
+	// every node with a readiness verdict hash as "changed" on every single poll. Layer/
+	// LayerOrder are deliberately excluded: they're recomputed from topology on every
+	// NewConfig call, not from telemetry, so they don't belong in a hash meant to detect
+	// telemetry-only changes.
+	var ready bool
+	var reason string
+	if nd.Readiness != nil {
+		ready, reason = nd.Readiness.Ready, nd.Readiness.Reason
+	}
+	return fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%v|%v|%v|%v|%v|%v|%v|%v|%v|%s|%v|%v|%s",
+		nd.Rate, nd.Rate3xx, nd.Rate4xx, nd.Rate5xx, nd.RateOut, nd.RateTcpSent, nd.RateTcpSentOut,
+		nd.HasCB, nd.HasMissingSC, nd.HasVS, nd.IsDead, nd.IsEgress, nd.IsInaccessible, nd.IsOutside,
+		nd.IsRoot, nd.IsUnused, nd.IsMisconfigured, nd.DestServices, ready, reason))))
+}
+
+func edgeTelemetryHash(ed *EdgeData) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%v|%v|%v|%s",
+		ed.Rate, ed.Rate3xx, ed.Rate4xx, ed.Rate5xx, ed.PercentErr, ed.PercentRate,
+		ed.ResponseTime, ed.ResponseTimeQuantiles, ed.IsMTLS, ed.IsUnused, ed.TcpSentRate))))
+}
@@ -0,0 +1,276 @@
+// Package dot provides a Graphviz DOT vendor for graph.TrafficMap, a sibling to the
+// cytoscape vendor. The produced text can be piped directly into `dot`/`neato` for
+// offline rendering, diffing, and embedding in reports.
+//
+// Main page: https://graphviz.org/doc/info/lang.html
+package dot
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/kiali/kiali/graph"
+	"github.com/kiali/kiali/graph/options"
+)
+
+// Config is the DOT vendor's equivalent of cytoscape.Config: a fully rendered
+// representation of a graph.TrafficMap, ready to be written to an http.ResponseWriter.
+type Config struct {
+	Timestamp int64
+	GraphType string
+	Dot       string
+}
+
+type graphNode struct {
+	id       string
+	nodeType string
+	name     string
+	version  string
+	attrs    map[string]string
+}
+
+type graphEdge struct {
+	sourceId string
+	destId   string
+	attrs    map[string]string
+}
+
+// NewConfig builds the DOT representation of trafficMap. It mirrors cytoscape.NewConfig:
+// namespaces become subgraphs, GroupByVersion compound nodes become clusters, and edge
+// labels carry rate/percentErr/responseTime.
+func NewConfig(trafficMap graph.TrafficMap, o options.VendorOptions) Config {
+	nodesByNamespace := make(map[string][]*graphNode)
+	edges := []*graphEdge{}
+
+	for id, n := range trafficMap {
+		gn := &graphNode{
+			id:       id,
+			nodeType: n.NodeType,
+			name:     nodeName(n),
+			version:  n.Version,
+			attrs:    nodeAttrs(n),
+		}
+		nodesByNamespace[n.Namespace] = append(nodesByNamespace[n.Namespace], gn)
+
+		for _, e := range n.Edges {
+			edges = append(edges, &graphEdge{
+				sourceId: n.ID,
+				destId:   e.Dest.ID,
+				attrs:    edgeAttrs(e),
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph kiali {\n")
+	buf.WriteString("  rankdir=LR;\n")
+
+	namespaces := make([]string, 0, len(nodesByNamespace))
+	for ns := range nodesByNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	for _, ns := range namespaces {
+		nodes := nodesByNamespace[ns]
+		// trafficMap is a map, so the nodes collected into this namespace arrived in a
+		// random order; sort by id for deterministic output (diffable reports are the
+		// whole point of this vendor).
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].id < nodes[j].id })
+		writeNamespaceSubgraph(&buf, ns, nodes, o)
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].sourceId != edges[j].sourceId {
+			return edges[i].sourceId < edges[j].sourceId
+		}
+		return edges[i].destId < edges[j].destId
+	})
+	for _, e := range edges {
+		buf.WriteString(fmt.Sprintf("  %q -> %q%s;\n", e.sourceId, e.destId, attrString(e.attrs)))
+	}
+
+	buf.WriteString("}\n")
+
+	return Config{
+		Timestamp: o.Timestamp,
+		GraphType: o.GraphType,
+		Dot:       buf.String(),
+	}
+}
+
+func writeNamespaceSubgraph(buf *bytes.Buffer, namespace string, nodes []*graphNode, o options.VendorOptions) {
+	buf.WriteString(fmt.Sprintf("  subgraph %q {\n", "cluster_ns_"+namespace))
+	buf.WriteString(fmt.Sprintf("    label=%q;\n", namespace))
+
+	grouped := groupByVersion(nodes, o)
+	groupKeys := make([]string, 0, len(grouped))
+	for groupKey := range grouped {
+		groupKeys = append(groupKeys, groupKey)
+	}
+	sort.Strings(groupKeys)
+
+	emitted := make(map[string]bool)
+	for _, groupKey := range groupKeys {
+		members := grouped[groupKey]
+		if len(members) < 2 {
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("    subgraph %q {\n", "cluster_app_"+groupKey))
+		buf.WriteString(fmt.Sprintf("      label=%q;\n", groupKey))
+		for _, n := range members {
+			writeNode(buf, n, "      ")
+			emitted[n.id] = true
+		}
+		buf.WriteString("    }\n")
+	}
+
+	for _, n := range nodes {
+		if emitted[n.id] {
+			continue // already emitted inside its app cluster above
+		}
+		writeNode(buf, n, "    ")
+	}
+
+	buf.WriteString("  }\n")
+}
+
+func writeNode(buf *bytes.Buffer, n *graphNode, indent string) {
+	label := n.name
+	if n.version != "" {
+		label = fmt.Sprintf("%s\\n%s", n.name, n.version)
+	}
+	attrs := make(map[string]string, len(n.attrs)+1)
+	for k, v := range n.attrs {
+		attrs[k] = v
+	}
+	attrs["label"] = label
+	buf.WriteString(fmt.Sprintf("%s%q%s;\n", indent, n.id, attrString(attrs)))
+}
+
+// groupByVersion buckets NodeTypeApp nodes by namespace+app, the same aggregation
+// cytoscape's applyGrouping uses to produce compound "version" boxes. Only a GroupBy of
+// exactly "version" is honored here; stacked or other grouping modes produce no clusters.
+func groupByVersion(nodes []*graphNode, o options.VendorOptions) map[string][]*graphNode {
+	grouped := make(map[string][]*graphNode)
+	if o.GraphType != graph.GraphTypeVersionedApp || o.GroupBy != options.GroupByVersion {
+		return grouped
+	}
+	for _, n := range nodes {
+		if n.nodeType != graph.NodeTypeApp {
+			continue
+		}
+		grouped[n.name] = append(grouped[n.name], n)
+	}
+	return grouped
+}
+
+func nodeName(n *graph.Node) string {
+	switch n.NodeType {
+	case graph.NodeTypeService:
+		return n.Service
+	case graph.NodeTypeApp:
+		if n.App != "" {
+			return n.App
+		}
+		return n.Workload
+	case graph.NodeTypeUnknown:
+		return "unknown"
+	default:
+		return n.Workload
+	}
+}
+
+func nodeAttrs(n *graph.Node) map[string]string {
+	attrs := map[string]string{"shape": "box"}
+
+	if boolMeta(n, "isDead") {
+		attrs["style"] = "dashed"
+		attrs["color"] = "gray"
+	}
+	if boolMeta(n, "hasMissingSC") {
+		attrs["color"] = "orange"
+	}
+	if boolMeta(n, "hasCB") {
+		attrs["peripheries"] = "2"
+	}
+	if boolMeta(n, "hasVS") {
+		attrs["style"] = "bold"
+	}
+	if boolMeta(n, "isUnused") {
+		attrs["fontcolor"] = "gray"
+	}
+	if n.NodeType == graph.NodeTypeService {
+		attrs["shape"] = "ellipse"
+	}
+
+	return attrs
+}
+
+func edgeAttrs(e *graph.Edge) map[string]string {
+	attrs := map[string]string{}
+
+	rate := floatMeta(e, "rate")
+	if rate > 0.0 {
+		label := fmt.Sprintf("%.2f", rate)
+		if rt, ok := e.Metadata["responseTime"]; ok {
+			label = fmt.Sprintf("%s (%.2fms)", label, rt.(float64))
+		}
+		rate4xx := floatMeta(e, "rate4xx")
+		rate5xx := floatMeta(e, "rate5xx")
+		if rate4xx+rate5xx > 0.0 {
+			label = fmt.Sprintf("%s %.1f%%err", label, (rate4xx+rate5xx)/rate*100.0)
+			attrs["color"] = "red"
+		}
+		attrs["label"] = label
+	}
+
+	if val, ok := e.Metadata["isMTLS"]; ok && val.(bool) {
+		attrs["style"] = "bold"
+	}
+
+	return attrs
+}
+
+func boolMeta(n *graph.Node, key string) bool {
+	if val, ok := n.Metadata[key]; ok {
+		if b, ok := val.(bool); ok {
+			return b
+		}
+	}
+	return false
+}
+
+func floatMeta(e *graph.Edge, key string) float64 {
+	if val, ok := e.Metadata[key]; ok {
+		if f, ok := val.(float64); ok {
+			return f
+		}
+	}
+	return 0.0
+}
+
+// attrString renders attrs as a DOT attribute list, e.g. ` [label="x",shape=box]`,
+// with deterministic key ordering for stable output.
+func attrString(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString(" [")
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(fmt.Sprintf("%s=%q", k, attrs[k]))
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
@@ -3,16 +3,18 @@
 package graph
 
 import (
-	"fmt"
 	"time"
 )
 
 const (
 	GraphTypeApp          string = "app"
+	GraphTypeGateway      string = "gateway" // Kubernetes Gateway API: Gateway listeners -> Routes -> backing services/workloads
 	GraphTypeService      string = "service" // Treated as graphType Workload, with service injection, and then condensed
 	GraphTypeVersionedApp string = "versionedApp"
 	GraphTypeWorkload     string = "workload"
 	NodeTypeApp           string = "app"
+	NodeTypeGateway       string = "gateway" // a Gateway API Gateway listener
+	NodeTypeRoute         string = "route"   // a Gateway API HTTPRoute/TCPRoute/etc
 	NodeTypeService       string = "service"
 	NodeTypeUnknown       string = "unknown" // The special "unknown" traffic gen node
 	NodeTypeWorkload      string = "workload"
@@ -88,7 +90,7 @@ func NewNodeExplicit(id, namespace, workload, app, version, service, nodeType, g
 		version = ""
 	}
 
-	return Node{
+	n := Node{
 		ID:        id,
 		NodeType:  nodeType,
 		Namespace: namespace,
@@ -99,6 +101,16 @@ func NewNodeExplicit(id, namespace, workload, app, version, service, nodeType, g
 		Edges:     []*Edge{},
 		Metadata:  make(map[string]interface{}),
 	}
+
+	// Every node gets a readiness verdict as soon as it's built, defaulting to ready
+	// since no backing objects are known yet. The telemetry builder that resolves a
+	// node's actual Deployment/StatefulSet/Pod/etc from the cluster (business/kubernetes
+	// in the full tree, not present in this snapshot) is expected to call
+	// SetNodeReadiness again with those objects once it has them, refining this verdict
+	// before the node reaches buildConfig.
+	SetNodeReadiness(&n, NewReadinessChecker())
+
+	return n
 }
 
 func (s *Node) AddEdge(dest *Node) *Edge {
@@ -119,55 +131,21 @@ func NewTrafficMap() TrafficMap {
 	return make(map[string]*Node)
 }
 
+// Id computes the unique node ID and NodeType for the given dimensions and graphType.
+// It dispatches through the GraphTypeSpec registry (see RegisterGraphType), so third
+// parties can add new graph types without editing this function. Id panics on failure
+// to preserve its historical contract; new code that wants a recoverable error should
+// call ResolveId directly.
 func Id(namespace, workload, app, version, service, graphType string) (id, nodeType string) {
-	// first, check for the special-case "unknown" source node
-	if UnknownNamespace == namespace && UnknownWorkload == workload && UnknownApp == app && "" == service {
-		return fmt.Sprintf("unknown_source"), NodeTypeUnknown
-	}
-
-	// It is possible that a request is made for an unknown destination. For example, an Ingress
-	// request to an unknown path. In this case the namespace may or may not be unknown.
-	// Every other field is unknown. Allow one unknown service per namespace to help reflect these
-	// bad destinations in the graph,  it may help diagnose a problem.
-	if UnknownWorkload == workload && UnknownApp == app && UnknownService == service {
-		return fmt.Sprintf("svc_%s_unknown", namespace), NodeTypeService
-	}
-
-	workloadOk := workload != "" && workload != UnknownWorkload
-	appOk := app != "" && app != UnknownApp
-	serviceOk := service != "" && service != UnknownService
-
-	if !workloadOk && !appOk && !serviceOk {
-		panic(fmt.Sprintf("Failed ID gen: namespace=[%s] workload=[%s] app=[%s] version=[%s] service=[%s] graphType=[%s]", namespace, workload, app, version, service, graphType))
-	}
-
-	// handle workload graph nodes (service graphs are initially processed as workload graphs)
-	if graphType == GraphTypeWorkload || graphType == GraphTypeService {
-		// workload graph nodes are type workload or service
-		if !workloadOk && !serviceOk {
-			panic(fmt.Sprintf("Failed ID gen: namespace=[%s] workload=[%s] app=[%s] version=[%s] service=[%s] graphType=[%s]", namespace, workload, app, version, service, graphType))
-		}
-		if !workloadOk {
-			return fmt.Sprintf("svc_%v_%v", namespace, service), NodeTypeService
-		}
-		return fmt.Sprintf("wl_%v_%v", namespace, workload), NodeTypeWorkload
-	}
-
-	// handle app nodes
-	if appOk {
-		// For a versionedApp graph we use workload as the Id, it allows us some protection against labeling
-		// anti-patterns. For versionless we  just use the app label to aggregate versions/workloads into one node
-		if graphType == GraphTypeVersionedApp {
-			return fmt.Sprintf("vapp_%v_%v", namespace, workload), NodeTypeApp
-		}
-		return fmt.Sprintf("app_%v_%v", namespace, app), NodeTypeApp
-	}
-
-	// fall back to workload if applicable
-	if workloadOk {
-		return fmt.Sprintf("wl_%v_%v", namespace, workload), NodeTypeWorkload
+	id, nodeType, err := ResolveId(NodeDescriptor{
+		Namespace: namespace,
+		Workload:  workload,
+		App:       app,
+		Version:   version,
+		Service:   service,
+	}, graphType)
+	if err != nil {
+		panic(err.Error())
 	}
-
-	// fall back to service as a last resort in the app graph
-	return fmt.Sprintf("svc_%v_%v", namespace, service), NodeTypeService
+	return id, nodeType
 }
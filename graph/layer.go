@@ -0,0 +1,324 @@
+package graph
+
+import "sort"
+
+// Layering strategies supported by Layer.
+const (
+	TopoOrder        string = "topoOrder"
+	ReverseTopoOrder string = "reverseTopoOrder"
+	SourceFirst      string = "sourceFirst"
+)
+
+// LayerOptions controls how Layer assigns layers to the nodes of a TrafficMap.
+type LayerOptions struct {
+	// Order selects the layering strategy: TopoOrder, ReverseTopoOrder or SourceFirst.
+	Order string
+	// ShowWholeGraph, when false, prunes nodes that are not reachable from Roots before
+	// layering, mirroring the "show whole graph" toggle in the UI.
+	ShowWholeGraph bool
+	// Roots is the set of node IDs considered reachability roots when ShowWholeGraph is false.
+	Roots []string
+}
+
+// Layer computes a topological layering of tm and attaches it to each node as
+// Metadata["layer"] / Metadata["layerOrder"]. Edges that close a cycle are broken (the
+// lowest-traffic edge in the cycle is marked Metadata["back-edge"]=true) so that a strict
+// layering can always be produced.
+func Layer(tm TrafficMap, opts LayerOptions) error {
+	working := tm
+	if !opts.ShowWholeGraph {
+		working = pruneUnreachable(tm, opts.Roots)
+	}
+
+	breakCycles(working)
+
+	var layers map[string]int
+	switch opts.Order {
+	case ReverseTopoOrder:
+		layers = topoLayers(working, true)
+	case SourceFirst:
+		layers = sourceFirstLayers(working)
+	default:
+		layers = topoLayers(working, false)
+	}
+
+	assignLayerOrder(working, layers)
+
+	return nil
+}
+
+// pruneUnreachable returns a new TrafficMap containing only nodes reachable from roots.
+func pruneUnreachable(tm TrafficMap, roots []string) TrafficMap {
+	reachable := make(map[string]bool)
+	queue := append([]string{}, roots...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if reachable[id] {
+			continue
+		}
+		reachable[id] = true
+		if n, ok := tm[id]; ok {
+			for _, e := range n.Edges {
+				queue = append(queue, e.Dest.ID)
+			}
+		}
+	}
+
+	pruned := NewTrafficMap()
+	for id, n := range tm {
+		if reachable[id] {
+			pruned[id] = n
+		}
+	}
+	return pruned
+}
+
+// breakCycles removes cycles from tm by marking the lowest-traffic edge of each detected
+// cycle as a back-edge, which excludes it from the topological computation below.
+func breakCycles(tm TrafficMap) {
+	visiting := make(map[string]bool)
+	visited := make(map[string]bool)
+	var path []*Edge // edges on the current DFS path, in visit order
+
+	var visit func(id string)
+	visit = func(id string) {
+		if visited[id] {
+			return
+		}
+		n, ok := tm[id]
+		if !ok {
+			return
+		}
+		visiting[id] = true
+
+		for _, e := range n.Edges {
+			if isBackEdge(e) {
+				continue
+			}
+			if visiting[e.Dest.ID] {
+				breakLowestTrafficEdge(append(append([]*Edge{}, path...), e))
+				continue
+			}
+			path = append(path, e)
+			visit(e.Dest.ID)
+			path = path[:len(path)-1]
+		}
+
+		visiting[id] = false
+		visited[id] = true
+	}
+
+	for id := range tm {
+		visit(id)
+	}
+}
+
+// breakLowestTrafficEdge marks the lowest-rate edge among candidates as a back-edge.
+// candidates is the full DFS path plus the edge that closed the cycle; only the
+// suffix of the path from the cycle's entry point onward is actually part of the
+// cycle, so that suffix is isolated first.
+func breakLowestTrafficEdge(candidates []*Edge) {
+	closingEdge := candidates[len(candidates)-1]
+	start := len(candidates) - 1
+	for i := len(candidates) - 2; i >= 0; i-- {
+		if candidates[i].Source.ID == closingEdge.Dest.ID {
+			start = i
+			break
+		}
+	}
+	cycle := candidates[start:]
+
+	lowest := cycle[0]
+	for _, e := range cycle[1:] {
+		if edgeRate(e) < edgeRate(lowest) {
+			lowest = e
+		}
+	}
+	markBackEdge(lowest)
+}
+
+func markBackEdge(e *Edge) {
+	e.Metadata["back-edge"] = true
+}
+
+func isBackEdge(e *Edge) bool {
+	back, ok := e.Metadata["back-edge"]
+	return ok && back.(bool)
+}
+
+func edgeRate(e *Edge) float64 {
+	if r, ok := e.Metadata["rate"]; ok {
+		if f, ok := r.(float64); ok {
+			return f
+		}
+	}
+	return 0.0
+}
+
+// topoLayers performs a Kahn-style longest-path ranking: a node's layer is one more than
+// the maximum layer of its (non-back-edge) predecessors. reverse flips the direction so
+// sinks become layer 0 instead of sources.
+func topoLayers(tm TrafficMap, reverse bool) map[string]int {
+	indegree := make(map[string]int)
+	preds := make(map[string][]string)
+	for id := range tm {
+		indegree[id] = 0
+	}
+	for id, n := range tm {
+		for _, e := range n.Edges {
+			if isBackEdge(e) {
+				continue
+			}
+			from, to := id, e.Dest.ID
+			if reverse {
+				from, to = to, from
+			}
+			indegree[to]++
+			preds[to] = append(preds[to], from)
+		}
+	}
+
+	layers := make(map[string]int)
+	var queue []string
+	for id, deg := range indegree {
+		if deg == 0 {
+			layers[id] = 0
+			queue = append(queue, id)
+		}
+	}
+	sort.Strings(queue)
+
+	adj := make(map[string][]string)
+	for id, n := range tm {
+		for _, e := range n.Edges {
+			if isBackEdge(e) {
+				continue
+			}
+			from, to := id, e.Dest.ID
+			if reverse {
+				from, to = to, from
+			}
+			adj[from] = append(adj[from], to)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range adj[id] {
+			if layers[id]+1 > layers[next] {
+				layers[next] = layers[id] + 1
+			}
+			indegree[next]--
+			if indegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+		sort.Strings(queue)
+	}
+
+	// any node untouched (isolated, or left over from a cycle edge case) gets layer 0
+	for id := range tm {
+		if _, ok := layers[id]; !ok {
+			layers[id] = 0
+		}
+	}
+
+	_ = preds // preds retained for readability/debuggability, not otherwise consumed
+	return layers
+}
+
+// sourceFirstLayers runs a BFS from nodes with no inbound edges (e.g. unknown_source,
+// ingress) and assigns each node the distance from the nearest such root.
+func sourceFirstLayers(tm TrafficMap) map[string]int {
+	hasInbound := make(map[string]bool)
+	for _, n := range tm {
+		for _, e := range n.Edges {
+			if !isBackEdge(e) {
+				hasInbound[e.Dest.ID] = true
+			}
+		}
+	}
+
+	var roots []string
+	for id := range tm {
+		if !hasInbound[id] {
+			roots = append(roots, id)
+		}
+	}
+	sort.Strings(roots)
+
+	layers := make(map[string]int)
+	queue := append([]string{}, roots...)
+	for _, id := range roots {
+		layers[id] = 0
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		n, ok := tm[id]
+		if !ok {
+			continue
+		}
+		for _, e := range n.Edges {
+			if isBackEdge(e) {
+				continue
+			}
+			if _, seen := layers[e.Dest.ID]; !seen {
+				layers[e.Dest.ID] = layers[id] + 1
+				queue = append(queue, e.Dest.ID)
+			}
+		}
+	}
+
+	// unreachable nodes (no path from any root) fall back to layer 0
+	for id := range tm {
+		if _, ok := layers[id]; !ok {
+			layers[id] = 0
+		}
+	}
+
+	return layers
+}
+
+// assignLayerOrder writes Metadata["layer"] and a stable Metadata["layerOrder"] (secondary
+// ordering by namespace, then node name) to every node in tm.
+func assignLayerOrder(tm TrafficMap, layers map[string]int) {
+	byLayer := make(map[int][]*Node)
+	for id, layer := range layers {
+		n, ok := tm[id]
+		if !ok {
+			continue
+		}
+		n.Metadata["layer"] = layer
+		byLayer[layer] = append(byLayer[layer], n)
+	}
+
+	for _, nodes := range byLayer {
+		sort.Slice(nodes, func(i, j int) bool {
+			if nodes[i].Namespace != nodes[j].Namespace {
+				return nodes[i].Namespace < nodes[j].Namespace
+			}
+			return nodeName(nodes[i]) < nodeName(nodes[j])
+		})
+		for order, n := range nodes {
+			n.Metadata["layerOrder"] = order
+		}
+	}
+}
+
+func nodeName(n *Node) string {
+	switch n.NodeType {
+	case NodeTypeService:
+		return n.Service
+	case NodeTypeApp:
+		if n.App != "" {
+			return n.App
+		}
+		return n.Workload
+	default:
+		return n.Workload
+	}
+}
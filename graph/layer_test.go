@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func addTestEdge(s, d *Node, rate float64) {
+	e := s.AddEdge(d)
+	e.Metadata["rate"] = rate
+}
+
+func TestLayerMultiNamespaceFanOut(t *testing.T) {
+	assert := assert.New(t)
+
+	tm := NewTrafficMap()
+	src := NewNodeExplicit("wl_a_src", "a", "src", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	mid1 := NewNodeExplicit("wl_b_mid1", "b", "mid1", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	mid2 := NewNodeExplicit("wl_c_mid2", "c", "mid2", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	leaf := NewNodeExplicit("wl_d_leaf", "d", "leaf", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	tm[src.ID] = &src
+	tm[mid1.ID] = &mid1
+	tm[mid2.ID] = &mid2
+	tm[leaf.ID] = &leaf
+
+	addTestEdge(&src, &mid1, 1)
+	addTestEdge(&src, &mid2, 1)
+	addTestEdge(&mid1, &leaf, 1)
+	addTestEdge(&mid2, &leaf, 1)
+
+	err := Layer(tm, LayerOptions{Order: TopoOrder, ShowWholeGraph: true})
+	assert.NoError(err)
+	assert.Equal(0, tm[src.ID].Metadata["layer"])
+	assert.Equal(1, tm[mid1.ID].Metadata["layer"])
+	assert.Equal(1, tm[mid2.ID].Metadata["layer"])
+	assert.Equal(2, tm[leaf.ID].Metadata["layer"])
+}
+
+func TestLayerBreaksCyclesBetweenTwoWorkloads(t *testing.T) {
+	assert := assert.New(t)
+
+	tm := NewTrafficMap()
+	a := NewNodeExplicit("wl_ns_a", "ns", "a", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	b := NewNodeExplicit("wl_ns_b", "ns", "b", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	tm[a.ID] = &a
+	tm[b.ID] = &b
+
+	addTestEdge(&a, &b, 10)
+	addTestEdge(&b, &a, 1) // lower traffic, should become the back-edge
+
+	err := Layer(tm, LayerOptions{Order: TopoOrder, ShowWholeGraph: true})
+	assert.NoError(err)
+
+	backEdges := 0
+	for _, e := range tm[b.ID].Edges {
+		if isBackEdge(e) {
+			backEdges++
+		}
+	}
+	assert.Equal(1, backEdges)
+	assert.Equal(0, tm[a.ID].Metadata["layer"])
+	assert.Equal(1, tm[b.ID].Metadata["layer"])
+}
+
+func TestLayerSourceFirstFromUnknownSource(t *testing.T) {
+	assert := assert.New(t)
+
+	tm := NewTrafficMap()
+	unknown := NewNodeExplicit("unknown_source", UnknownNamespace, UnknownWorkload, UnknownApp, UnknownVersion, "", NodeTypeUnknown, GraphTypeWorkload)
+	ingress := NewNodeExplicit("wl_ns_ingress", "ns", "ingress", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	backend := NewNodeExplicit("wl_ns_backend", "ns", "backend", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	tm[unknown.ID] = &unknown
+	tm[ingress.ID] = &ingress
+	tm[backend.ID] = &backend
+
+	addTestEdge(&unknown, &ingress, 1)
+	addTestEdge(&ingress, &backend, 1)
+
+	err := Layer(tm, LayerOptions{Order: SourceFirst, ShowWholeGraph: true})
+	assert.NoError(err)
+	assert.Equal(0, tm[unknown.ID].Metadata["layer"])
+	assert.Equal(1, tm[ingress.ID].Metadata["layer"])
+	assert.Equal(2, tm[backend.ID].Metadata["layer"])
+}
+
+func TestLayerServiceWithNoBackingWorkload(t *testing.T) {
+	assert := assert.New(t)
+
+	tm := NewTrafficMap()
+	src := NewNodeExplicit("wl_ns_src", "ns", "src", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	svc := NewNodeExplicit("svc_ns_orphan", "ns", "", "", "", "orphan", NodeTypeService, GraphTypeWorkload)
+	tm[src.ID] = &src
+	tm[svc.ID] = &svc
+
+	addTestEdge(&src, &svc, 1)
+
+	err := Layer(tm, LayerOptions{Order: TopoOrder, ShowWholeGraph: true})
+	assert.NoError(err)
+	assert.Equal(0, tm[src.ID].Metadata["layer"])
+	assert.Equal(1, tm[svc.ID].Metadata["layer"])
+}
+
+func TestLayerPrunesUnreachableNodesWhenNotShowingWholeGraph(t *testing.T) {
+	assert := assert.New(t)
+
+	tm := NewTrafficMap()
+	root := NewNodeExplicit("wl_ns_root", "ns", "root", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	reachable := NewNodeExplicit("wl_ns_reachable", "ns", "reachable", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	orphan := NewNodeExplicit("wl_ns_orphan", "ns", "orphan", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	tm[root.ID] = &root
+	tm[reachable.ID] = &reachable
+	tm[orphan.ID] = &orphan
+
+	addTestEdge(&root, &reachable, 1)
+
+	err := Layer(tm, LayerOptions{Order: TopoOrder, ShowWholeGraph: false, Roots: []string{root.ID}})
+	assert.NoError(err)
+	_, orphanGotLayer := tm[orphan.ID].Metadata["layer"]
+	assert.False(orphanGotLayer)
+	assert.Equal(0, tm[root.ID].Metadata["layer"])
+	assert.Equal(1, tm[reachable.ID].Metadata["layer"])
+}
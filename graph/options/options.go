@@ -2,6 +2,7 @@
 package options
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -11,6 +12,9 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	ot "github.com/opentracing/opentracing-go"
+	k8s_fields "k8s.io/apimachinery/pkg/fields"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
 
 	"github.com/kiali/kiali/business"
 	"github.com/kiali/kiali/graph"
@@ -19,7 +23,9 @@ import (
 
 const (
 	AppenderAll               string = "_all_"
+	GroupByNamespace          string = "namespace"
 	GroupByVersion            string = "version"
+	GroupByWorkload           string = "workload"
 	NamespaceAll              string = "all"
 	NamespaceIstioSystem      string = "istio-system"
 	defaultDuration           string = "10m"
@@ -27,9 +33,26 @@ const (
 	defaultGroupBy            string = GroupByVersion
 	defaultIncludeIstio       bool   = false
 	defaultInjectServiceNodes bool   = false
+	defaultShowWholeGraph     bool   = true
 	defaultVendor             string = "cytoscape"
 )
 
+// validGraphProtocols mirrors handlers.protocolMetricFamilies' key set (duplicated here,
+// rather than imported, since handlers already imports this package). A protocol= query
+// param naming one of these restricts which Prometheus families back the traffic map,
+// the same way the namespace metrics endpoint's protocol= param restricts its own query,
+// so e.g. protocol=tcp stops TCP-only edges from getting pruned as "no traffic" by
+// whatever builds the TrafficMap from HTTP/gRPC-only families.
+var validGraphProtocols = map[string]bool{
+	"http": true,
+	"grpc": true,
+	"tcp":  true,
+}
+
+// defaultQuantiles is the response time quantile reported on edges when the caller
+// doesn't supply quantiles[].
+var defaultQuantiles = []string{"0.95"}
+
 const (
 	graphKindNamespace string = "namespace"
 	graphKindNode      string = "node"
@@ -37,16 +60,25 @@ const (
 
 // NodeOptions are those that apply only to node-detail graphs
 type NodeOptions struct {
-	App      string
-	Service  string
-	Version  string
-	Workload string
+	App              string
+	Service          string
+	Version          string
+	Workload         string
+	GatewayName      string // Gateway API Gateway name, from the {gateway} path variable. GraphTypeGateway only.
+	GatewayNamespace string // defaults to the graph's namespace; Gateway API allows routes to reference a Gateway in another namespace.
+	RouteKind        string // optional route-filtering query param, e.g. "HTTPRoute", "TCPRoute". GraphTypeGateway only.
+	RouteName        string // optional route-filtering query param. GraphTypeGateway only.
 }
 
 // VendorOptions are those that are supplied to the vendor-specific generators.
 type VendorOptions struct {
 	GraphType string
 	GroupBy   string
+	// Layering controls how the cytoscape vendor computes graph.Layer: the topological
+	// ordering strategy, whether to prune to Roots first, and the root set itself. See
+	// the layerOrder=/showWholeGraph=/roots= query params in NewOptions.
+	Layering  graph.LayerOptions
+	Quantiles []string // response time quantiles to report on edges, e.g. "0.5", "0.95", "0.99"
 	Timestamp int64
 }
 
@@ -54,17 +86,31 @@ type VendorOptions struct {
 type Options struct {
 	AccessibleNamespaces map[string]bool
 	Appenders            []appender.Appender
-	Duration             time.Duration
-	IncludeIstio         bool // include istio-system services. Ignored for istio-system ns. Default false.
-	InjectServiceNodes   bool // inject destination service nodes between source and destination nodes.
-	Namespaces           map[string]graph.NamespaceInfo
-	QueryTime            int64 // unix time in seconds
-	Vendor               string
+	// Ctx carries the server span started by routing's tracing middleware (see
+	// routing.tracingMiddleware), when tracing is enabled. Appenders read it out of
+	// Options (rather than taking it as a parameter) so that each one's Prometheus/
+	// Kubernetes calls can open a child span via ot.StartSpanFromContext(o.Ctx, ...).
+	Ctx                context.Context
+	Duration           time.Duration
+	IncludeIstio       bool // include istio-system services. Ignored for istio-system ns. Default false.
+	InjectServiceNodes bool // inject destination service nodes between source and destination nodes.
+	Namespaces         map[string]graph.NamespaceInfo
+	// Protocol restricts the traffic map to a single protocol's metric families (one of
+	// "http", "grpc", "tcp"), same as handlers.extractMetricsQueryParams does for the
+	// namespace metrics endpoint. Empty means no restriction: all protocols are queried.
+	Protocol  string
+	QueryTime int64 // unix time in seconds
+	Vendor    string
 	NodeOptions
 	VendorOptions
 }
 
 func NewOptions(r *http.Request) Options {
+	// The request context carries the server span started by routing's tracing
+	// middleware (when tracing is enabled); propagate it so the namespace/Prometheus/
+	// Kubernetes calls issued while computing the graph show up as child spans.
+	ctx := r.Context()
+
 	// path variables
 	vars := mux.Vars(r)
 	app := vars["app"]
@@ -72,6 +118,7 @@ func NewOptions(r *http.Request) Options {
 	requestedNamespace := vars["namespace"]
 	service := vars["service"]
 	workload := vars["workload"]
+	gatewayName := vars["gateway"] // set for /api/namespaces/{namespace}/gateways/{gateway}/graph
 
 	// query params
 	params := r.URL.Query()
@@ -83,6 +130,16 @@ func NewOptions(r *http.Request) Options {
 	queryTime, queryTimeErr := strconv.ParseInt(params.Get("queryTime"), 10, 64)
 	requestedNamespaces := params.Get("namespaces") // csl of namespaces. Overrides namespace path param if set
 	vendor := params.Get("vendor")
+	quantiles, quantilesOk := params["quantiles[]"]
+	namespaceLabelsParam := params.Get("namespaceLabels")
+	namespaceFieldsParam := params.Get("namespaceFields")
+	gatewayNamespace := params.Get("gatewayNamespace") // cross-namespace Gateway reference; defaults to requestedNamespace
+	routeKind := params.Get("routeKind")
+	routeName := params.Get("routeName")
+	layerOrder := params.Get("layerOrder")
+	showWholeGraph, showWholeGraphErr := strconv.ParseBool(params.Get("showWholeGraph"))
+	rootsParam := params.Get("roots") // csl of node IDs; only consulted when showWholeGraph=false
+	protocol := params.Get("protocol")
 
 	// Set defaults, if needed.
 	if durationErr != nil {
@@ -106,9 +163,33 @@ func NewOptions(r *http.Request) Options {
 	if "" == vendor {
 		vendor = defaultVendor
 	}
+	if !quantilesOk || len(quantiles) == 0 {
+		quantiles = defaultQuantiles
+	}
+	switch layerOrder {
+	case graph.ReverseTopoOrder, graph.SourceFirst:
+		// explicit, non-default strategy requested
+	default:
+		layerOrder = graph.TopoOrder
+	}
+	if showWholeGraphErr != nil {
+		showWholeGraph = defaultShowWholeGraph
+	}
+	var roots []string
+	if rootsParam != "" {
+		roots = strings.Split(rootsParam, ",")
+	}
+	if protocol != "" && !validGraphProtocols[protocol] {
+		checkError(errors.New("Bad request, query parameter 'protocol' must be one of 'http', 'grpc' or 'tcp'"))
+	}
 
 	// Process namespaces options
-	accessibleNamespaces, namespaceTimestamps := getAccessibleNamespaces()
+	accessibleNamespaces, namespaceTimestamps, namespaceLabelSets := getAccessibleNamespaces(ctx)
+
+	selectorNamespaces, selectorErr := resolveSelectorNamespaces(accessibleNamespaces, namespaceLabelSets, namespaceLabelsParam, namespaceFieldsParam)
+	if selectorErr != nil {
+		checkError(selectorErr)
+	}
 
 	namespaces := make(map[string]graph.NamespaceInfo)
 	fetchNamespaces := requestedNamespaces == NamespaceAll || (requestedNamespaces == "" && (requestedNamespace == NamespaceAll))
@@ -145,6 +226,30 @@ func NewOptions(r *http.Request) Options {
 				Duration: resolveNamespaceDuration(namespaceTimestamps[requestedNamespace], duration, queryTime),
 			}
 		}
+	} else if selectorNamespaces != nil {
+		// no namespaces=/namespace path variable was supplied; namespaceLabels=/namespaceFields=
+		// alone pins the graph to the logical slice of the mesh the selector resolves to.
+		for namespace := range selectorNamespaces {
+			if namespace != NamespaceIstioSystem {
+				namespaces[namespace] = graph.NamespaceInfo{
+					Name:     namespace,
+					Duration: resolveNamespaceDuration(namespaceTimestamps[namespace], duration, queryTime),
+				}
+			}
+		}
+	}
+
+	// namespaceLabels=/namespaceFields= combine with an explicit namespaces=/namespace by
+	// intersection: it can only narrow the set above, never widen it.
+	if selectorNamespaces != nil && len(namespaces) > 0 {
+		for namespace := range namespaces {
+			if !selectorNamespaces[namespace] {
+				delete(namespaces, namespace)
+			}
+		}
+		if len(namespaces) == 0 {
+			checkError(errors.New("Bad request, no requested namespace matches the 'namespaceLabels'/'namespaceFields' selector"))
+		}
 	}
 
 	// Service graphs require service injection
@@ -152,23 +257,43 @@ func NewOptions(r *http.Request) Options {
 		injectServiceNodes = true
 	}
 
+	// Gateway graphs default the Gateway's namespace to the graph's own namespace;
+	// Gateway API's ReferenceGrant mechanism is what allows a route to point at a
+	// Gateway in a different namespace, so gatewayNamespace is only ever set to
+	// something else via the explicit query param.
+	if graphType == graph.GraphTypeGateway && gatewayNamespace == "" {
+		gatewayNamespace = requestedNamespace
+	}
+
 	options := Options{
 		AccessibleNamespaces: accessibleNamespaces,
+		Ctx:                  ctx,
 		Duration:             duration,
 		IncludeIstio:         includeIstio,
 		InjectServiceNodes:   injectServiceNodes,
 		Namespaces:           namespaces,
+		Protocol:             protocol,
 		QueryTime:            queryTime,
 		Vendor:               vendor,
 		NodeOptions: NodeOptions{
-			App:      app,
-			Service:  service,
-			Version:  version,
-			Workload: workload,
+			App:              app,
+			Service:          service,
+			Version:          version,
+			Workload:         workload,
+			GatewayName:      gatewayName,
+			GatewayNamespace: gatewayNamespace,
+			RouteKind:        routeKind,
+			RouteName:        routeName,
 		},
 		VendorOptions: VendorOptions{
 			GraphType: graphType,
 			GroupBy:   groupBy,
+			Layering: graph.LayerOptions{
+				Order:          layerOrder,
+				ShowWholeGraph: showWholeGraph,
+				Roots:          roots,
+			},
+			Quantiles: quantiles,
 			Timestamp: queryTime,
 		},
 	}
@@ -184,76 +309,26 @@ func (o *Options) GetGraphKind() string {
 	if o.NodeOptions.App != "" ||
 		o.NodeOptions.Version != "" ||
 		o.NodeOptions.Workload != "" ||
-		o.NodeOptions.Service != "" {
+		o.NodeOptions.Service != "" ||
+		o.NodeOptions.GatewayName != "" {
 		return graphKindNode
 	} else {
 		return graphKindNamespace
 	}
 }
 
-func parseAppenders(params url.Values, o Options) []appender.Appender {
-	var appenders []appender.Appender
-	csl := AppenderAll
-	_, ok := params["appenders"]
-	if ok {
-		csl = strings.ToLower(params.Get("appenders"))
-	}
-
-	// The appender order is important
-	// To reduce processing, filter dead services first
-	// To reduce processing, next run appenders that don't apply to unused services
-	// Add orphan (unused) services
-	// Run remaining appenders
-	if csl == AppenderAll || strings.Contains(csl, appender.DeadNodeAppenderName) || strings.Contains(csl, "dead_node") {
-		appenders = append(appenders, appender.DeadNodeAppender{})
-	}
-	if csl == AppenderAll || strings.Contains(csl, appender.ResponseTimeAppenderName) || strings.Contains(csl, "response_time") {
-		quantile := appender.DefaultQuantile
-		if _, ok := params["responseTimeQuantile"]; ok {
-			if responseTimeQuantile, err := strconv.ParseFloat(params.Get("responseTimeQuantile"), 64); err == nil {
-				quantile = responseTimeQuantile
-			}
-		}
-		a := appender.ResponseTimeAppender{
-			Quantile:           quantile,
-			GraphType:          o.GraphType,
-			InjectServiceNodes: o.InjectServiceNodes,
-			IncludeIstio:       o.IncludeIstio,
-			Namespaces:         o.Namespaces,
-			QueryTime:          o.QueryTime,
-		}
-		appenders = append(appenders, a)
-	}
-	if csl == AppenderAll || strings.Contains(csl, appender.SecurityPolicyAppenderName) || strings.Contains(csl, "security_policy") {
-		a := appender.SecurityPolicyAppender{
-			GraphType:    o.GraphType,
-			IncludeIstio: o.IncludeIstio,
-			Namespaces:   o.Namespaces,
-			QueryTime:    o.QueryTime,
-		}
-		appenders = append(appenders, a)
-	}
-	if csl == AppenderAll || strings.Contains(csl, appender.UnusedNodeAppenderName) || strings.Contains(csl, "unused_node") {
-		hasNodeOptions := o.App != "" || o.Workload != "" || o.Service != ""
-		appenders = append(appenders, appender.UnusedNodeAppender{
-			GraphType:   o.GraphType,
-			IsNodeGraph: hasNodeOptions,
-		})
-	}
-	if csl == AppenderAll || strings.Contains(csl, appender.IstioAppenderName) || strings.Contains(csl, "istio") {
-		appenders = append(appenders, appender.IstioAppender{})
-	}
-	if csl == AppenderAll || strings.Contains(csl, appender.SidecarsCheckAppenderName) || strings.Contains(csl, "sidecars_check") {
-		appenders = append(appenders, appender.SidecarsCheckAppender{})
-	}
-
-	return appenders
-}
+// parseAppenders lives in registry.go: it walks the pluggable appender registry
+// instead of a hardcoded if-chain, so third-party appenders and a kiali.yaml
+// graph.appenders block can participate without editing this function.
 
 // getAccessibleNamespaces returns a Set of all namespaces accessible to the user.
 // The Set is implemented using the map[string]bool convention.
-// Additionally, a map with the creation timestamps of the namespaces is returned.
-func getAccessibleNamespaces() (map[string]bool, map[string]time.Time) {
+// Additionally, a map with the creation timestamps of the namespaces, and a map with
+// the labels of the namespaces (for namespaceLabels= selector matching), are returned.
+func getAccessibleNamespaces(ctx context.Context) (map[string]bool, map[string]time.Time, map[string]map[string]string) {
+	span, _ := ot.StartSpanFromContext(ctx, "getAccessibleNamespaces")
+	defer span.Finish()
+
 	// Get the namespaces
 	business, err := business.Get()
 	checkError(err)
@@ -264,12 +339,60 @@ func getAccessibleNamespaces() (map[string]bool, map[string]time.Time) {
 	// Create a map to store the namespaces
 	namespaceMap := make(map[string]bool)
 	namespaceTimestamps := make(map[string]time.Time)
+	namespaceLabels := make(map[string]map[string]string)
 	for _, namespace := range namespaces {
 		namespaceMap[namespace.Name] = true
 		namespaceTimestamps[namespace.Name] = namespace.CreationTimestamp
+		namespaceLabels[namespace.Name] = namespace.Labels
+	}
+
+	return namespaceMap, namespaceTimestamps, namespaceLabels
+}
+
+// resolveSelectorNamespaces resolves namespaceLabels/namespaceFields query parameters
+// into the set of accessible namespaces they match. It returns (nil, nil) when neither
+// parameter was supplied, meaning "no selector restriction". A matching-but-inaccessible
+// namespace (i.e. one that is not a key of accessibleNamespaces) is never included,
+// since selector resolution only ever narrows the accessible set, it never widens it.
+func resolveSelectorNamespaces(accessibleNamespaces map[string]bool, namespaceLabelSets map[string]map[string]string, namespaceLabelsParam, namespaceFieldsParam string) (map[string]bool, error) {
+	if namespaceLabelsParam == "" && namespaceFieldsParam == "" {
+		return nil, nil
+	}
+
+	var labelSelector k8s_labels.Selector
+	if namespaceLabelsParam != "" {
+		sel, err := k8s_labels.Parse(namespaceLabelsParam)
+		if err != nil {
+			return nil, fmt.Errorf("bad request, invalid 'namespaceLabels' selector: %s", err.Error())
+		}
+		labelSelector = sel
+	}
+
+	var fieldSelector k8s_fields.Selector
+	if namespaceFieldsParam != "" {
+		sel, err := k8s_fields.ParseSelector(namespaceFieldsParam)
+		if err != nil {
+			return nil, fmt.Errorf("bad request, invalid 'namespaceFields' selector: %s", err.Error())
+		}
+		fieldSelector = sel
+	}
+
+	matched := make(map[string]bool)
+	for namespace := range accessibleNamespaces {
+		if labelSelector != nil && !labelSelector.Matches(k8s_labels.Set(namespaceLabelSets[namespace])) {
+			continue
+		}
+		if fieldSelector != nil && !fieldSelector.Matches(k8s_fields.Set{"metadata.name": namespace}) {
+			continue
+		}
+		matched[namespace] = true
+	}
+
+	if len(matched) == 0 {
+		return nil, errors.New("bad request, no accessible namespace matches the requested 'namespaceLabels'/'namespaceFields' selector")
 	}
 
-	return namespaceMap, namespaceTimestamps
+	return matched, nil
 }
 
 func checkError(err error) {
@@ -0,0 +1,281 @@
+package options
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kiali/kiali/graph"
+	"github.com/kiali/kiali/graph/appender"
+)
+
+// appenderEntry is a single slot in the appender pipeline. It replaces the hardcoded
+// if-chain that used to live in parseAppenders: each appender registers its own name,
+// a default position in the pipeline, and the logic to build itself from the request's
+// query parameters.
+type appenderEntry struct {
+	// Name matches the value used in the appenders= CSV and in the per-appender
+	// "disabled cluster-wide" configuration.
+	Name string
+	// DefaultOrder is this appender's position absent any kiali.yaml override. Lower
+	// values run first; see enforceOrderInvariants below for the invariants this preserves
+	// (DeadNode before others, UnusedNode before Istio).
+	DefaultOrder int
+	// AppliesTo reports whether this appender is relevant for o; an appender that
+	// doesn't apply is skipped even when requested.
+	AppliesTo func(o Options) bool
+	// ParseParams builds the appender from the request's query parameters and o.
+	ParseParams func(params url.Values, o Options) (appender.Appender, error)
+}
+
+// appenderRegistry is the default pipeline, in its historical order. New entries are
+// added with MustRegisterAppender.
+var appenderRegistry = []appenderEntry{}
+
+// appenderOrderOverride and disabledAppendersGlobally let an operator's kiali.yaml
+// graph.appenders block change the default ordering or disable an appender
+// cluster-wide; see ConfigureAppenders.
+var appenderOrderOverride = map[string]int{}
+var disabledAppendersGlobally = map[string]bool{}
+
+// appenderAliases preserves the historical underscore-separated names the old
+// hardcoded appenders= switch accepted alongside each entry's canonical Name, so a
+// caller's existing appenders= CSV (e.g. "dead_node,response_time") keeps working.
+var appenderAliases = map[string][]string{
+	appender.DeadNodeAppenderName:       {"dead_node"},
+	appender.ResponseTimeAppenderName:   {"response_time"},
+	appender.SecurityPolicyAppenderName: {"security_policy"},
+	appender.UnusedNodeAppenderName:     {"unused_node"},
+	appender.SidecarsCheckAppenderName:  {"sidecars_check"},
+}
+
+func init() {
+	MustRegisterAppender(appenderEntry{
+		Name:         appender.DeadNodeAppenderName,
+		DefaultOrder: 0,
+		AppliesTo:    func(Options) bool { return true },
+		ParseParams: func(params url.Values, o Options) (appender.Appender, error) {
+			return appender.DeadNodeAppender{Ctx: o.Ctx}, nil
+		},
+	})
+	MustRegisterAppender(appenderEntry{
+		Name:         appender.ResponseTimeAppenderName,
+		DefaultOrder: 1,
+		AppliesTo:    func(Options) bool { return true },
+		ParseParams: func(params url.Values, o Options) (appender.Appender, error) {
+			quantile := appender.DefaultQuantile
+			if _, ok := params["responseTimeQuantile"]; ok {
+				parsedQuantile, err := strconv.ParseFloat(params.Get("responseTimeQuantile"), 64)
+				if err != nil {
+					return nil, err
+				}
+				quantile = parsedQuantile
+			}
+			return appender.ResponseTimeAppender{
+				Ctx:                o.Ctx,
+				Quantile:           quantile,
+				GraphType:          o.GraphType,
+				InjectServiceNodes: o.InjectServiceNodes,
+				IncludeIstio:       o.IncludeIstio,
+				Namespaces:         o.Namespaces,
+				QueryTime:          o.QueryTime,
+			}, nil
+		},
+	})
+	MustRegisterAppender(appenderEntry{
+		Name:         appender.SecurityPolicyAppenderName,
+		DefaultOrder: 2,
+		AppliesTo:    func(Options) bool { return true },
+		ParseParams: func(params url.Values, o Options) (appender.Appender, error) {
+			return appender.SecurityPolicyAppender{
+				Ctx:          o.Ctx,
+				GraphType:    o.GraphType,
+				IncludeIstio: o.IncludeIstio,
+				Namespaces:   o.Namespaces,
+				QueryTime:    o.QueryTime,
+			}, nil
+		},
+	})
+	MustRegisterAppender(appenderEntry{
+		Name:         appender.UnusedNodeAppenderName,
+		DefaultOrder: 3,
+		AppliesTo:    func(Options) bool { return true },
+		ParseParams: func(params url.Values, o Options) (appender.Appender, error) {
+			hasNodeOptions := o.App != "" || o.Workload != "" || o.Service != ""
+			return appender.UnusedNodeAppender{
+				Ctx:         o.Ctx,
+				GraphType:   o.GraphType,
+				IsNodeGraph: hasNodeOptions,
+			}, nil
+		},
+	})
+	MustRegisterAppender(appenderEntry{
+		Name:         appender.IstioAppenderName,
+		DefaultOrder: 4,
+		AppliesTo:    func(Options) bool { return true },
+		ParseParams: func(params url.Values, o Options) (appender.Appender, error) {
+			return appender.IstioAppender{Ctx: o.Ctx}, nil
+		},
+	})
+	MustRegisterAppender(appenderEntry{
+		Name:         appender.SidecarsCheckAppenderName,
+		DefaultOrder: 5,
+		AppliesTo:    func(Options) bool { return true },
+		ParseParams: func(params url.Values, o Options) (appender.Appender, error) {
+			return appender.SidecarsCheckAppender{Ctx: o.Ctx}, nil
+		},
+	})
+	MustRegisterAppender(appenderEntry{
+		Name:         appender.GatewayAPIAppenderName,
+		DefaultOrder: 6,
+		// Gateway API nodes/edges (Gateway listeners, Route parentRefs/backendRefs,
+		// ReferenceGrant-gated cross-namespace references) only make sense on a
+		// graphType=gateway request; every other graph type skips this appender
+		// entirely, same as it would skip a graphType=gateway-only appender today.
+		AppliesTo: func(o Options) bool { return o.GraphType == graph.GraphTypeGateway },
+		ParseParams: func(params url.Values, o Options) (appender.Appender, error) {
+			return appender.GatewayAPIAppender{
+				Ctx:              o.Ctx,
+				Namespaces:       o.Namespaces,
+				QueryTime:        o.QueryTime,
+				GatewayName:      o.GatewayName,
+				GatewayNamespace: o.GatewayNamespace,
+				RouteKind:        o.RouteKind,
+				RouteName:        o.RouteName,
+			}, nil
+		},
+	})
+}
+
+// MustRegisterAppender adds entry to the pipeline, or replaces the existing entry of
+// the same Name. The built-in appenders are registered from this file's init().
+// appenderEntry is unexported, so only code inside package options can build an entry
+// to register; other packages cannot call it.
+func MustRegisterAppender(entry appenderEntry) {
+	for i, existing := range appenderRegistry {
+		if existing.Name == entry.Name {
+			appenderRegistry[i] = entry
+			return
+		}
+	}
+	appenderRegistry = append(appenderRegistry, entry)
+}
+
+// ConfigureAppenders applies a kiali.yaml graph.appenders block: order pins the
+// default ordering (appenders not listed keep their DefaultOrder, appended after the
+// pinned ones), and disabled turns appenders off cluster-wide regardless of what a
+// caller's appenders= CSV requests.
+func ConfigureAppenders(order []string, disabled []string) {
+	appenderOrderOverride = make(map[string]int, len(order))
+	for i, name := range order {
+		appenderOrderOverride[name] = i
+	}
+	disabledAppendersGlobally = make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		disabledAppendersGlobally[name] = true
+	}
+}
+
+func appenderOrder(name string) int {
+	if pos, ok := appenderOrderOverride[name]; ok {
+		return pos
+	}
+	// keep pinned appenders first, defaulted ones after, in their DefaultOrder
+	for _, entry := range appenderRegistry {
+		if entry.Name == name {
+			return len(appenderOrderOverride) + entry.DefaultOrder
+		}
+	}
+	return len(appenderOrderOverride) + len(appenderRegistry)
+}
+
+// orderedAppenderEntries returns the registry sorted into its effective pipeline
+// order, applying any ConfigureAppenders override, then correcting the result so the
+// override can never violate the pipeline's two hard ordering invariants (see
+// enforceOrderInvariants).
+func orderedAppenderEntries() []appenderEntry {
+	ordered := make([]appenderEntry, len(appenderRegistry))
+	copy(ordered, appenderRegistry)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return appenderOrder(ordered[i].Name) < appenderOrder(ordered[j].Name)
+	})
+	return enforceOrderInvariants(ordered)
+}
+
+// enforceOrderInvariants repositions entries, if necessary, so that a kiali.yaml
+// order override (see ConfigureAppenders) can never violate the two invariants the
+// appender pipeline depends on for correctness: DeadNode must run before every other
+// appender (it prunes the dead nodes the rest shouldn't see), and UnusedNode must run
+// before Istio (the Istio badges assume unused nodes are already flagged). A violating
+// override is silently corrected rather than rejected; appenders= still controls which
+// appenders actually run, ConfigureAppenders only ever reshuffles the ones that do.
+func enforceOrderInvariants(ordered []appenderEntry) []appenderEntry {
+	indexOf := func(name string) int {
+		for i, e := range ordered {
+			if e.Name == name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if i := indexOf(appender.DeadNodeAppenderName); i > 0 {
+		entry := ordered[i]
+		ordered = append(ordered[:i], ordered[i+1:]...)
+		ordered = append([]appenderEntry{entry}, ordered...)
+	}
+
+	if i, j := indexOf(appender.UnusedNodeAppenderName), indexOf(appender.IstioAppenderName); i != -1 && j != -1 && i > j {
+		entry := ordered[i]
+		ordered = append(ordered[:i], ordered[i+1:]...)
+		j = indexOf(appender.IstioAppenderName)
+		rest := append([]appenderEntry{entry}, ordered[j:]...)
+		ordered = append(ordered[:j], rest...)
+	}
+
+	return ordered
+}
+
+// parseAppenders walks the appender registry in configured order, honoring the
+// appenders= CSV (or AppenderAll) and any cluster-wide disables from ConfigureAppenders.
+// A malformed per-appender parameter (e.g. responseTimeQuantile) surfaces as a checkError
+// panic, same as every other bad-request case in NewOptions.
+func parseAppenders(params url.Values, o Options) []appender.Appender {
+	csl := AppenderAll
+	if _, ok := params["appenders"]; ok {
+		csl = strings.ToLower(params.Get("appenders"))
+	}
+
+	var appenders []appender.Appender
+	for _, entry := range orderedAppenderEntries() {
+		if disabledAppendersGlobally[entry.Name] {
+			continue
+		}
+		if csl != AppenderAll && !matchesAppenderCSL(csl, entry.Name) {
+			continue
+		}
+		if !entry.AppliesTo(o) {
+			continue
+		}
+		a, err := entry.ParseParams(params, o)
+		checkError(err)
+		appenders = append(appenders, a)
+	}
+
+	return appenders
+}
+
+// matchesAppenderCSL reports whether the appenders= CSV csl requests name, matching
+// either its canonical registry Name or one of its historical appenderAliases.
+func matchesAppenderCSL(csl, name string) bool {
+	if strings.Contains(csl, strings.ToLower(name)) {
+		return true
+	}
+	for _, alias := range appenderAliases[name] {
+		if strings.Contains(csl, alias) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package options
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kiali/kiali/graph"
+	"github.com/kiali/kiali/graph/appender"
+)
+
+func entryPositions() map[string]int {
+	positions := map[string]int{}
+	for i, entry := range orderedAppenderEntries() {
+		positions[entry.Name] = i
+	}
+	return positions
+}
+
+func TestParseAppendersDefaultOrderPreservesInvariants(t *testing.T) {
+	assert := assert.New(t)
+
+	positions := entryPositions()
+
+	assert.Less(positions[appender.DeadNodeAppenderName], positions[appender.ResponseTimeAppenderName])
+	assert.Less(positions[appender.DeadNodeAppenderName], positions[appender.IstioAppenderName])
+	assert.Less(positions[appender.UnusedNodeAppenderName], positions[appender.IstioAppenderName])
+}
+
+func TestParseAppendersHonorsAppendersCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	params := url.Values{"appenders": []string{appender.DeadNodeAppenderName}}
+	appenders := parseAppenders(params, Options{})
+
+	assert.Len(appenders, 1)
+}
+
+func TestParseAppendersConfigureAppendersDisablesGlobally(t *testing.T) {
+	assert := assert.New(t)
+
+	ConfigureAppenders(nil, []string{appender.SidecarsCheckAppenderName})
+	defer ConfigureAppenders(nil, nil)
+
+	// GraphType must be "gateway" here so GatewayAPIAppender's AppliesTo also holds;
+	// otherwise it would drop out for a reason unrelated to the disable this test covers.
+	appenders := parseAppenders(url.Values{}, Options{VendorOptions: VendorOptions{GraphType: graph.GraphTypeGateway}})
+	assert.Len(appenders, len(appenderRegistry)-1)
+}
+
+func TestParseAppendersGatewayAPIAppenderOnlyAppliesToGatewayGraphs(t *testing.T) {
+	assert := assert.New(t)
+
+	appenders := parseAppenders(url.Values{}, Options{VendorOptions: VendorOptions{GraphType: graph.GraphTypeWorkload}})
+	for _, a := range appenders {
+		assert.NotIsType(appender.GatewayAPIAppender{}, a)
+	}
+
+	gatewayAppenders := parseAppenders(url.Values{}, Options{VendorOptions: VendorOptions{GraphType: graph.GraphTypeGateway}})
+	found := false
+	for _, a := range gatewayAppenders {
+		if _, ok := a.(appender.GatewayAPIAppender); ok {
+			found = true
+		}
+	}
+	assert.True(found)
+}
+
+func TestParseAppendersConfigureAppendersOverridesOrderWithinInvariants(t *testing.T) {
+	assert := assert.New(t)
+
+	ConfigureAppenders([]string{appender.SidecarsCheckAppenderName, appender.ResponseTimeAppenderName}, nil)
+	defer ConfigureAppenders(nil, nil)
+
+	positions := entryPositions()
+	assert.Less(positions[appender.SidecarsCheckAppenderName], positions[appender.ResponseTimeAppenderName])
+}
+
+func TestParseAppendersConfigureAppendersCannotViolateInvariants(t *testing.T) {
+	assert := assert.New(t)
+
+	// An override naively placing Istio before DeadNode (and, transitively, before
+	// UnusedNode) must be corrected, not honored: DeadNode-before-everything and
+	// UnusedNode-before-Istio are invariants the appenders themselves rely on.
+	ConfigureAppenders([]string{appender.IstioAppenderName, appender.UnusedNodeAppenderName, appender.DeadNodeAppenderName}, nil)
+	defer ConfigureAppenders(nil, nil)
+
+	positions := entryPositions()
+	assert.Less(positions[appender.DeadNodeAppenderName], positions[appender.IstioAppenderName])
+	assert.Less(positions[appender.UnusedNodeAppenderName], positions[appender.IstioAppenderName])
+}
+
+func TestParseAppendersHonorsUnderscoreAliases(t *testing.T) {
+	assert := assert.New(t)
+
+	params := url.Values{"appenders": []string{"dead_node,unused_node"}}
+	appenders := parseAppenders(params, Options{})
+
+	assert.Len(appenders, 2)
+}
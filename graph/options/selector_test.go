@@ -0,0 +1,74 @@
+package options
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSelectorNamespacesNoSelectorMeansNoRestriction(t *testing.T) {
+	assert := assert.New(t)
+
+	matched, err := resolveSelectorNamespaces(map[string]bool{"a": true}, map[string]map[string]string{}, "", "")
+	assert.NoError(err)
+	assert.Nil(matched)
+}
+
+func TestResolveSelectorNamespacesLabelSelector(t *testing.T) {
+	assert := assert.New(t)
+
+	accessible := map[string]bool{"payments": true, "shipping": true}
+	labelSets := map[string]map[string]string{
+		"payments": {"team": "payments", "env": "prod"},
+		"shipping": {"team": "shipping", "env": "prod"},
+	}
+
+	matched, err := resolveSelectorNamespaces(accessible, labelSets, "team=payments", "")
+	assert.NoError(err)
+	assert.Equal(map[string]bool{"payments": true}, matched)
+}
+
+func TestResolveSelectorNamespacesExcludesInaccessibleMatch(t *testing.T) {
+	assert := assert.New(t)
+
+	// "restricted" matches the label selector but is not RBAC-accessible, so it must
+	// never show up in the resolved set.
+	accessible := map[string]bool{"payments": true}
+	labelSets := map[string]map[string]string{
+		"payments":   {"team": "payments"},
+		"restricted": {"team": "payments"},
+	}
+
+	matched, err := resolveSelectorNamespaces(accessible, labelSets, "team=payments", "")
+	assert.NoError(err)
+	assert.Equal(map[string]bool{"payments": true}, matched)
+	assert.NotContains(matched, "restricted")
+}
+
+func TestResolveSelectorNamespacesFieldSelectorOnMetadataName(t *testing.T) {
+	assert := assert.New(t)
+
+	accessible := map[string]bool{"payments": true, "shipping": true}
+	labelSets := map[string]map[string]string{"payments": {}, "shipping": {}}
+
+	matched, err := resolveSelectorNamespaces(accessible, labelSets, "", "metadata.name=payments")
+	assert.NoError(err)
+	assert.Equal(map[string]bool{"payments": true}, matched)
+}
+
+func TestResolveSelectorNamespacesEmptyResultIsAnError(t *testing.T) {
+	assert := assert.New(t)
+
+	accessible := map[string]bool{"payments": true}
+	labelSets := map[string]map[string]string{"payments": {"team": "payments"}}
+
+	_, err := resolveSelectorNamespaces(accessible, labelSets, "team=shipping", "")
+	assert.Error(err)
+}
+
+func TestResolveSelectorNamespacesInvalidSelectorIsAnError(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := resolveSelectorNamespaces(map[string]bool{"a": true}, map[string]map[string]string{"a": {}}, "team in (", "")
+	assert.Error(err)
+}
@@ -0,0 +1,186 @@
+package graph
+
+import (
+	"time"
+
+	osappsv1 "github.com/openshift/api/apps/v1"
+	apps_v1 "k8s.io/api/apps/v1"
+	"k8s.io/api/apps/v1beta1"
+	"k8s.io/api/apps/v1beta2"
+	batch_v1 "k8s.io/api/batch/v1"
+	batch_v1beta1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NodeReadiness is the aggregated readiness verdict for the kubernetes object(s)
+// backing a graph Node. It is attached to Node.Metadata under the "readiness" key
+// so that the UI can badge an unhealthy node distinctly from a node that simply
+// has no traffic.
+type NodeReadiness struct {
+	Ready          bool      `json:"ready"`
+	Reason         string    `json:"reason"`
+	LastTransition time.Time `json:"lastTransition"`
+}
+
+// ReadinessChecker resolves the readiness of the kubernetes object(s) backing a
+// single graph Node. Implementations are expected to be cheap enough to run once
+// per node per graph generation; expensive lookups (e.g. listing pods) should be
+// done by the caller and passed in via the object arguments.
+type ReadinessChecker interface {
+	// IsReady inspects the supplied kubernetes objects (Deployment, StatefulSet, Pod, etc.)
+	// and reports whether the node they back is ready, along with a short reason.
+	IsReady(objects ...interface{}) (ready bool, reason string)
+}
+
+// k8sReadinessChecker implements ReadinessChecker using the same per-kind rules
+// Helm 3.5 uses to decide whether a release's resources have become ready.
+type k8sReadinessChecker struct{}
+
+// NewReadinessChecker returns the default ReadinessChecker.
+func NewReadinessChecker() ReadinessChecker {
+	return k8sReadinessChecker{}
+}
+
+func (k8sReadinessChecker) IsReady(objects ...interface{}) (bool, string) {
+	if len(objects) == 0 {
+		return true, "no backing objects to check"
+	}
+
+	for _, obj := range objects {
+		if ready, reason := isObjectReady(obj); !ready {
+			return false, reason
+		}
+	}
+
+	return true, "all backing objects are ready"
+}
+
+// isObjectReady applies the Helm-style readiness predicate for a single kubernetes
+// object. Unrecognized types are treated as ready since Kiali has no opinion on them.
+func isObjectReady(obj interface{}) (bool, string) {
+	switch o := obj.(type) {
+	case *apps_v1.Deployment:
+		var maxUnavailable *intstr.IntOrString
+		if o.Spec.Strategy.RollingUpdate != nil {
+			maxUnavailable = o.Spec.Strategy.RollingUpdate.MaxUnavailable
+		}
+		return isDeploymentReady(o.Status.ObservedGeneration, o.Generation, o.Status.AvailableReplicas, o.Spec.Replicas, maxUnavailable)
+	case *v1beta1.Deployment:
+		var maxUnavailable *intstr.IntOrString
+		if o.Spec.Strategy.RollingUpdate != nil {
+			maxUnavailable = o.Spec.Strategy.RollingUpdate.MaxUnavailable
+		}
+		return isDeploymentReady(o.Status.ObservedGeneration, o.Generation, o.Status.AvailableReplicas, o.Spec.Replicas, maxUnavailable)
+	case *v1beta2.Deployment:
+		var maxUnavailable *intstr.IntOrString
+		if o.Spec.Strategy.RollingUpdate != nil {
+			maxUnavailable = o.Spec.Strategy.RollingUpdate.MaxUnavailable
+		}
+		return isDeploymentReady(o.Status.ObservedGeneration, o.Generation, o.Status.AvailableReplicas, o.Spec.Replicas, maxUnavailable)
+	case *apps_v1.StatefulSet:
+		if o.Status.ReadyReplicas != o.Status.Replicas {
+			return false, "statefulset: ready replicas do not match desired replicas"
+		}
+		return true, "statefulset is ready"
+	case *v1beta1.StatefulSet:
+		if o.Status.ReadyReplicas != o.Status.Replicas {
+			return false, "statefulset: ready replicas do not match desired replicas"
+		}
+		return true, "statefulset is ready"
+	case *v1beta2.StatefulSet:
+		if o.Status.ReadyReplicas != o.Status.Replicas {
+			return false, "statefulset: ready replicas do not match desired replicas"
+		}
+		return true, "statefulset is ready"
+	case *apps_v1.DaemonSet:
+		if o.Status.NumberReady != o.Status.DesiredNumberScheduled {
+			return false, "daemonset: not all desired pods are ready"
+		}
+		return true, "daemonset is ready"
+	case *v1beta2.DaemonSet:
+		if o.Status.NumberReady != o.Status.DesiredNumberScheduled {
+			return false, "daemonset: not all desired pods are ready"
+		}
+		return true, "daemonset is ready"
+	case *apps_v1.ReplicaSet:
+		return true, "replicaset has no independent readiness condition"
+	case *v1.ReplicationController:
+		return true, "replicationcontroller has no independent readiness condition"
+	case *osappsv1.DeploymentConfig:
+		if o.Status.AvailableReplicas < o.Status.Replicas {
+			return false, "deploymentconfig: available replicas below desired replicas"
+		}
+		return true, "deploymentconfig is ready"
+	case *batch_v1.Job:
+		if o.Status.Succeeded > 0 {
+			return true, "job has succeeded"
+		}
+		for _, c := range o.Status.Conditions {
+			if c.Type == batch_v1.JobComplete && c.Status == v1.ConditionTrue {
+				return true, "job is complete"
+			}
+		}
+		return false, "job has not yet succeeded"
+	case *batch_v1beta1.CronJob:
+		return true, "cronjob has no independent readiness condition"
+	case *v1.Pod:
+		if o.Status.Phase != v1.PodRunning {
+			return false, "pod is not in the Running phase"
+		}
+		for _, cs := range o.Status.ContainerStatuses {
+			if !cs.Ready {
+				return false, "pod has a container that is not ready"
+			}
+		}
+		return true, "pod is running with all containers ready"
+	case *v1.Service:
+		if o.Spec.Type == v1.ServiceTypeLoadBalancer && len(o.Status.LoadBalancer.Ingress) == 0 {
+			return false, "loadbalancer service has no ingress address"
+		}
+		return true, "service is ready"
+	default:
+		return true, "unrecognized object type, assuming ready"
+	}
+}
+
+// isDeploymentReady mirrors Helm's/kubectl rollout status's deployment readiness check:
+// the deployment's controller must have observed the latest spec, and enough replicas
+// must be available to satisfy spec.replicas less whatever RollingUpdate.maxUnavailable
+// explicitly allows to be missing.
+func isDeploymentReady(observedGeneration, generation int64, availableReplicas int32, specReplicas *int32, maxUnavailable *intstr.IntOrString) (bool, string) {
+	if observedGeneration < generation {
+		return false, "deployment: observed generation is behind the desired generation"
+	}
+	wanted := int32(1)
+	if specReplicas != nil {
+		wanted = *specReplicas
+	}
+
+	var allowedUnavailable int32
+	if maxUnavailable != nil {
+		if v, err := intstr.GetValueFromIntOrPercent(maxUnavailable, int(wanted), false); err == nil {
+			allowedUnavailable = int32(v)
+		}
+	}
+
+	minAvailable := wanted - allowedUnavailable
+	if minAvailable < 0 {
+		minAvailable = 0
+	}
+	if availableReplicas < minAvailable {
+		return false, "deployment: available replicas below the minimum allowed by maxUnavailable"
+	}
+	return true, "deployment is ready"
+}
+
+// SetNodeReadiness computes the readiness of n using checker and the supplied backing
+// objects, and records the verdict in n.Metadata["readiness"].
+func SetNodeReadiness(n *Node, checker ReadinessChecker, objects ...interface{}) {
+	ready, reason := checker.IsReady(objects...)
+	n.Metadata["readiness"] = NodeReadiness{
+		Ready:          ready,
+		Reason:         reason,
+		LastTransition: time.Now(),
+	}
+}
@@ -0,0 +1,151 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestReadinessPodRunningAndReady(t *testing.T) {
+	assert := assert.New(t)
+
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			Phase:             v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{{Ready: true}},
+		},
+	}
+
+	ready, reason := NewReadinessChecker().IsReady(pod)
+	assert.True(ready)
+	assert.NotEmpty(reason)
+}
+
+func TestReadinessPodWithUnreadyContainer(t *testing.T) {
+	assert := assert.New(t)
+
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			Phase:             v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{{Ready: false}},
+		},
+	}
+
+	ready, _ := NewReadinessChecker().IsReady(pod)
+	assert.False(ready)
+}
+
+func TestReadinessJobSucceeded(t *testing.T) {
+	assert := assert.New(t)
+
+	job := &batch_v1.Job{Status: batch_v1.JobStatus{Succeeded: 1}}
+
+	ready, _ := NewReadinessChecker().IsReady(job)
+	assert.True(ready)
+}
+
+func TestReadinessJobNotYetComplete(t *testing.T) {
+	assert := assert.New(t)
+
+	job := &batch_v1.Job{}
+
+	ready, reason := NewReadinessChecker().IsReady(job)
+	assert.False(ready)
+	assert.Contains(reason, "not yet succeeded")
+}
+
+func TestReadinessLoadBalancerServiceMissingIngress(t *testing.T) {
+	assert := assert.New(t)
+
+	svc := &v1.Service{Spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer}}
+
+	ready, reason := NewReadinessChecker().IsReady(svc)
+	assert.False(ready)
+	assert.Contains(reason, "ingress")
+}
+
+func TestReadinessAggregatesMultipleObjects(t *testing.T) {
+	assert := assert.New(t)
+
+	readyPod := &v1.Pod{Status: v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{{Ready: true}}}}
+	notReadyJob := &batch_v1.Job{}
+
+	ready, _ := NewReadinessChecker().IsReady(readyPod, notReadyJob)
+	assert.False(ready)
+}
+
+func TestReadinessAppsV1DeploymentBelowDesiredReplicas(t *testing.T) {
+	assert := assert.New(t)
+
+	replicas := int32(3)
+	deploy := &apps_v1.Deployment{
+		Spec:   apps_v1.DeploymentSpec{Replicas: &replicas},
+		Status: apps_v1.DeploymentStatus{AvailableReplicas: 1},
+	}
+
+	ready, reason := NewReadinessChecker().IsReady(deploy)
+	assert.False(ready)
+	assert.Contains(reason, "maxUnavailable")
+}
+
+func TestReadinessAppsV1DeploymentWithMaxUnavailableAllowsPartialAvailability(t *testing.T) {
+	assert := assert.New(t)
+
+	replicas := int32(3)
+	maxUnavailable := intstr.FromInt(2)
+	deploy := &apps_v1.Deployment{
+		Spec: apps_v1.DeploymentSpec{
+			Replicas: &replicas,
+			Strategy: apps_v1.DeploymentStrategy{
+				RollingUpdate: &apps_v1.RollingUpdateDeployment{MaxUnavailable: &maxUnavailable},
+			},
+		},
+		Status: apps_v1.DeploymentStatus{AvailableReplicas: 1},
+	}
+
+	ready, _ := NewReadinessChecker().IsReady(deploy)
+	assert.True(ready)
+}
+
+func TestReadinessAppsV1DeploymentObservedGenerationBehind(t *testing.T) {
+	assert := assert.New(t)
+
+	replicas := int32(1)
+	deploy := &apps_v1.Deployment{
+		Spec:   apps_v1.DeploymentSpec{Replicas: &replicas},
+		Status: apps_v1.DeploymentStatus{ObservedGeneration: 1, AvailableReplicas: 1},
+	}
+	deploy.Generation = 2
+
+	ready, reason := NewReadinessChecker().IsReady(deploy)
+	assert.False(ready)
+	assert.Contains(reason, "observed generation")
+}
+
+func TestNewNodeExplicitSetsDefaultReadiness(t *testing.T) {
+	assert := assert.New(t)
+
+	n := NewNodeExplicit("wl_ns_wk", "ns", "wk", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+
+	readiness, ok := n.Metadata["readiness"].(NodeReadiness)
+	assert.True(ok)
+	assert.True(readiness.Ready)
+}
+
+func TestSetNodeReadiness(t *testing.T) {
+	assert := assert.New(t)
+
+	n := NewNodeExplicit("wl_ns_wk", "ns", "wk", "", "", "", NodeTypeWorkload, GraphTypeWorkload)
+	pod := &v1.Pod{Status: v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{{Ready: true}}}}
+
+	SetNodeReadiness(&n, NewReadinessChecker(), pod)
+
+	readiness, ok := n.Metadata["readiness"].(NodeReadiness)
+	assert.True(ok)
+	assert.True(readiness.Ready)
+	assert.False(readiness.LastTransition.IsZero())
+}
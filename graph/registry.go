@@ -0,0 +1,150 @@
+package graph
+
+import "fmt"
+
+// NodeDescriptor carries the dimensions used to compute a node's ID and NodeType.
+// Cluster is new relative to the original namespace/workload/app/version/service
+// tuple; it is optional today (most graph types ignore it) but lets multi-cluster
+// graph types build IDs like "wl_<cluster>_<ns>_<wl>".
+type NodeDescriptor struct {
+	Namespace string
+	Cluster   string
+	Workload  string
+	App       string
+	Version   string
+	Service   string
+}
+
+// IDFunc computes the unique node ID and NodeType for a NodeDescriptor. It returns
+// an error, rather than panicking, when the descriptor does not carry enough
+// information to build an ID for the owning graph type.
+type IDFunc func(d NodeDescriptor) (id, nodeType string, err error)
+
+// GraphTypeSpec registers a graph type with the ID registry so third parties can
+// add new graph types without editing this package.
+type GraphTypeSpec struct {
+	// Name is the graphType value, e.g. graph.GraphTypeApp.
+	Name string
+	// NodeTypes lists the NodeType values this graph type can produce.
+	NodeTypes []string
+	// IDFunc computes the ID/NodeType for a node of this graph type.
+	IDFunc IDFunc
+}
+
+var graphTypeRegistry = make(map[string]GraphTypeSpec)
+
+// RegisterGraphType adds or replaces the GraphTypeSpec for spec.Name.
+func RegisterGraphType(spec GraphTypeSpec) {
+	graphTypeRegistry[spec.Name] = spec
+}
+
+func init() {
+	RegisterGraphType(GraphTypeSpec{
+		Name:      GraphTypeWorkload,
+		NodeTypes: []string{NodeTypeWorkload, NodeTypeService, NodeTypeUnknown},
+		IDFunc:    workloadGraphID,
+	})
+	RegisterGraphType(GraphTypeSpec{
+		Name:      GraphTypeService,
+		NodeTypes: []string{NodeTypeWorkload, NodeTypeService, NodeTypeUnknown},
+		IDFunc:    workloadGraphID,
+	})
+	RegisterGraphType(GraphTypeSpec{
+		Name:      GraphTypeApp,
+		NodeTypes: []string{NodeTypeApp, NodeTypeService, NodeTypeWorkload, NodeTypeUnknown},
+		IDFunc:    appGraphID(false),
+	})
+	RegisterGraphType(GraphTypeSpec{
+		Name:      GraphTypeVersionedApp,
+		NodeTypes: []string{NodeTypeApp, NodeTypeService, NodeTypeWorkload, NodeTypeUnknown},
+		IDFunc:    appGraphID(true),
+	})
+	RegisterGraphType(GraphTypeSpec{
+		Name:      GraphTypeGateway,
+		NodeTypes: []string{NodeTypeGateway, NodeTypeRoute, NodeTypeService, NodeTypeWorkload, NodeTypeUnknown},
+		// Gateway/Route node IDs are keyed by name+kind rather than by the
+		// workload/app/service dimensions NodeDescriptor carries today, so the
+		// GatewayAPIAppender that builds those nodes computes their IDs itself
+		// (see options.NodeOptions.GatewayName/RouteKind/RouteName). Terminal
+		// backing service/workload nodes reuse the same ID scheme as every other
+		// graph type.
+		IDFunc: workloadGraphID,
+	})
+}
+
+// ResolveId computes the node ID and NodeType for d under graphType, dispatching
+// through the GraphTypeSpec registry. Unlike the legacy Id(), it never panics;
+// callers that cannot build a valid ID get a descriptive error instead.
+func ResolveId(d NodeDescriptor, graphType string) (id, nodeType string, err error) {
+	// the special-case "unknown" source/destination nodes are graphType-agnostic
+	if id, nodeType, ok := unknownNodeId(d); ok {
+		return id, nodeType, nil
+	}
+
+	spec, ok := graphTypeRegistry[graphType]
+	if !ok {
+		return "", "", fmt.Errorf("unregistered graph type [%s]", graphType)
+	}
+	return spec.IDFunc(d)
+}
+
+func unknownNodeId(d NodeDescriptor) (id, nodeType string, ok bool) {
+	if UnknownNamespace == d.Namespace && UnknownWorkload == d.Workload && UnknownApp == d.App && "" == d.Service {
+		return "unknown_source", NodeTypeUnknown, true
+	}
+	if UnknownWorkload == d.Workload && UnknownApp == d.App && UnknownService == d.Service {
+		return clusterPrefixedId("svc", d.Cluster, fmt.Sprintf("%s_unknown", d.Namespace)), NodeTypeService, true
+	}
+	return "", "", false
+}
+
+func workloadGraphID(d NodeDescriptor) (id, nodeType string, err error) {
+	workloadOk := d.Workload != "" && d.Workload != UnknownWorkload
+	serviceOk := d.Service != "" && d.Service != UnknownService
+
+	if !workloadOk && !serviceOk {
+		return "", "", fmt.Errorf("failed ID gen: namespace=[%s] cluster=[%s] workload=[%s] service=[%s]", d.Namespace, d.Cluster, d.Workload, d.Service)
+	}
+	if !workloadOk {
+		return clusterPrefixedId("svc", d.Cluster, fmt.Sprintf("%s_%s", d.Namespace, d.Service)), NodeTypeService, nil
+	}
+	return clusterPrefixedId("wl", d.Cluster, fmt.Sprintf("%s_%s", d.Namespace, d.Workload)), NodeTypeWorkload, nil
+}
+
+// appGraphID returns the IDFunc for an app-family graph type. versioned selects the
+// versionedApp behavior, which uses the workload name (not the app label) as the Id
+// to guard against app/version labeling anti-patterns.
+func appGraphID(versioned bool) IDFunc {
+	return func(d NodeDescriptor) (id, nodeType string, err error) {
+		appOk := d.App != "" && d.App != UnknownApp
+		workloadOk := d.Workload != "" && d.Workload != UnknownWorkload
+		serviceOk := d.Service != "" && d.Service != UnknownService
+
+		if !workloadOk && !appOk && !serviceOk {
+			return "", "", fmt.Errorf("failed ID gen: namespace=[%s] cluster=[%s] workload=[%s] app=[%s] service=[%s]", d.Namespace, d.Cluster, d.Workload, d.App, d.Service)
+		}
+
+		if appOk {
+			if versioned {
+				return clusterPrefixedId("vapp", d.Cluster, fmt.Sprintf("%s_%s", d.Namespace, d.Workload)), NodeTypeApp, nil
+			}
+			return clusterPrefixedId("app", d.Cluster, fmt.Sprintf("%s_%s", d.Namespace, d.App)), NodeTypeApp, nil
+		}
+
+		if workloadOk {
+			return clusterPrefixedId("wl", d.Cluster, fmt.Sprintf("%s_%s", d.Namespace, d.Workload)), NodeTypeWorkload, nil
+		}
+
+		return clusterPrefixedId("svc", d.Cluster, fmt.Sprintf("%s_%s", d.Namespace, d.Service)), NodeTypeService, nil
+	}
+}
+
+// clusterPrefixedId builds "<prefix>_<rest>" for single-cluster graphs (the default,
+// and the only mode in use today) or "<prefix>_<cluster>_<rest>" once a graph type
+// opts into the Cluster dimension.
+func clusterPrefixedId(prefix, cluster, rest string) string {
+	if cluster == "" {
+		return fmt.Sprintf("%s_%s", prefix, rest)
+	}
+	return fmt.Sprintf("%s_%s_%s", prefix, cluster, rest)
+}
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveIdDefaultWorkloadGraph(t *testing.T) {
+	assert := assert.New(t)
+
+	id, nodeType, err := ResolveId(NodeDescriptor{Namespace: "ns", Workload: "wk"}, GraphTypeWorkload)
+	assert.NoError(err)
+	assert.Equal("wl_ns_wk", id)
+	assert.Equal(NodeTypeWorkload, nodeType)
+}
+
+func TestResolveIdReturnsErrorInsteadOfPanicking(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := ResolveId(NodeDescriptor{Namespace: "ns"}, GraphTypeWorkload)
+	assert.Error(err)
+}
+
+func TestResolveIdUnregisteredGraphType(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := ResolveId(NodeDescriptor{Namespace: "ns", Workload: "wk"}, "doesNotExist")
+	assert.Error(err)
+}
+
+func TestIdStillPanicsForBackwardCompatibility(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Panics(func() {
+		Id("ns", "", "", "", "", GraphTypeWorkload)
+	})
+}
+
+func TestRegisterGraphTypeForMultiClusterMeshType(t *testing.T) {
+	assert := assert.New(t)
+
+	const graphTypeMeshFederation = "mesh-federation"
+	RegisterGraphType(GraphTypeSpec{
+		Name:      graphTypeMeshFederation,
+		NodeTypes: []string{NodeTypeWorkload},
+		IDFunc: func(d NodeDescriptor) (string, string, error) {
+			return clusterPrefixedId("wl", d.Cluster, d.Namespace+"_"+d.Workload), NodeTypeWorkload, nil
+		},
+	})
+
+	id, nodeType, err := ResolveId(NodeDescriptor{Namespace: "ns", Cluster: "east", Workload: "wk"}, graphTypeMeshFederation)
+	assert.NoError(err)
+	assert.Equal("wl_east_ns_wk", id)
+	assert.Equal(NodeTypeWorkload, nodeType)
+}
+
+func TestResolveIdGatewayGraphBackingWorkload(t *testing.T) {
+	assert := assert.New(t)
+
+	// GraphTypeGateway's backing service/workload nodes use the same ID scheme as
+	// every other graph type; the Gateway/Route nodes themselves are ID'd by the
+	// GatewayAPIAppender, not by this registry.
+	id, nodeType, err := ResolveId(NodeDescriptor{Namespace: "ns", Workload: "wk"}, GraphTypeGateway)
+	assert.NoError(err)
+	assert.Equal("wl_ns_wk", id)
+	assert.Equal(NodeTypeWorkload, nodeType)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kiali/kiali/graph"
+	"github.com/kiali/kiali/graph/cytoscape"
+	"github.com/kiali/kiali/graph/dot"
+	"github.com/kiali/kiali/graph/options"
+)
+
+// graphConfigCache backs the delta graph endpoint (?delta=true&since=<timestamp>): it
+// holds the last full cytoscape.Config computed for a given request's query signature,
+// so that a subsequent poll of the same graph can be answered with a ConfigDelta
+// instead of the full payload. See graph/cytoscape/cache.go and delta.go.
+var graphConfigCache = cytoscape.NewCache(100)
+
+// writeGraphConfig is the single exit point every graph handler (GraphNamespaces,
+// GraphNode, ...) funnels trafficMap through: it picks the vendor named by
+// o.Vendor ("cytoscape", the default, or "dot") and writes the rendered result to w.
+// Adding a new vendor package only means adding a case here.
+//
+// For the cytoscape vendor, a request carrying delta=true is answered with a
+// cytoscape.ConfigDelta against the Config cached under the same query signature
+// (since= is accepted but only used as a staleness check: a cached Config older than
+// since is treated the same as a cold cache). A cold cache, a missing/stale entry, or
+// any other vendor all fall back to writing the full Config, same as a non-delta
+// request.
+func writeGraphConfig(trafficMap graph.TrafficMap, o options.Options, r *http.Request, w http.ResponseWriter) {
+	switch o.Vendor {
+	case "dot":
+		cfg := dot.NewConfig(trafficMap, o.VendorOptions)
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Write([]byte(cfg.Dot))
+	default:
+		cfg := cytoscape.NewConfig(trafficMap, o.VendorOptions)
+		w.Header().Set("Content-Type", "application/json")
+
+		key := graphCacheKey(r)
+		wantDelta, since := parseDeltaParams(r)
+		defer graphConfigCache.Set(key, cfg)
+
+		if wantDelta {
+			if prev, ok := graphConfigCache.Get(key); ok && prev.Timestamp >= since {
+				json.NewEncoder(w).Encode(cytoscape.NewConfigDelta(prev, cfg))
+				return
+			}
+			// cold cache, or the cached Config predates the client's "since": the client
+			// has nothing usable to diff against, so send the full Config instead.
+		}
+		json.NewEncoder(w).Encode(cfg)
+	}
+}
+
+// parseDeltaParams reads the delta=true&since=<unix-seconds> query parameters.
+// since defaults to 0 (the zero value matches any cached entry) when absent or
+// unparseable.
+func parseDeltaParams(r *http.Request) (wantDelta bool, since int64) {
+	params := r.URL.Query()
+	wantDelta, _ = strconv.ParseBool(params.Get("delta"))
+	since, _ = strconv.ParseInt(params.Get("since"), 10, 64)
+	return wantDelta, since
+}
+
+// graphCacheKey is the cache key for the Config produced by a graph request: the raw
+// query string, minus the delta/since params (which don't affect the graph that gets
+// computed), with params sorted so the same logical request always hashes the same.
+func graphCacheKey(r *http.Request) string {
+	params := r.URL.Query()
+	params.Del("delta")
+	params.Del("since")
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(r.URL.Path)
+	for _, k := range keys {
+		vals := append([]string{}, params[k]...)
+		sort.Strings(vals)
+		b.WriteByte('|')
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strings.Join(vals, ","))
+	}
+	return b.String()
+}
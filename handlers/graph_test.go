@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kiali/kiali/graph"
+	"github.com/kiali/kiali/graph/cytoscape"
+	"github.com/kiali/kiali/graph/options"
+)
+
+func TestWriteGraphConfigDefaultsToCytoscapeJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	r := httptest.NewRequest("GET", "/api/namespaces/ns/graph", nil)
+	w := httptest.NewRecorder()
+	writeGraphConfig(graph.NewTrafficMap(), options.Options{}, r, w)
+
+	assert.Equal("application/json", w.Header().Get("Content-Type"))
+}
+
+func TestWriteGraphConfigDispatchesToDot(t *testing.T) {
+	assert := assert.New(t)
+
+	r := httptest.NewRequest("GET", "/api/namespaces/ns/graph?vendor=dot", nil)
+	w := httptest.NewRecorder()
+	writeGraphConfig(graph.NewTrafficMap(), options.Options{VendorOptions: options.VendorOptions{}, Vendor: "dot"}, r, w)
+
+	assert.Contains(w.Header().Get("Content-Type"), "text/vnd.graphviz")
+}
+
+func TestWriteGraphConfigDeltaFallsBackToFullConfigOnColdCache(t *testing.T) {
+	assert := assert.New(t)
+
+	r := httptest.NewRequest("GET", "/api/namespaces/cold/graph?delta=true", nil)
+	w := httptest.NewRecorder()
+	writeGraphConfig(graph.NewTrafficMap(), options.Options{}, r, w)
+
+	var cfg cytoscape.Config
+	assert.NoError(json.Unmarshal(w.Body.Bytes(), &cfg))
+}
+
+func TestWriteGraphConfigDeltaReturnsDeltaOnWarmCache(t *testing.T) {
+	assert := assert.New(t)
+
+	url := "/api/namespaces/warm/graph?delta=true"
+	r := httptest.NewRequest("GET", url, nil)
+
+	first := httptest.NewRecorder()
+	writeGraphConfig(graph.NewTrafficMap(), options.Options{}, r, first)
+
+	second := httptest.NewRecorder()
+	writeGraphConfig(graph.NewTrafficMap(), options.Options{}, r, second)
+
+	var delta cytoscape.ConfigDelta
+	assert.NoError(json.Unmarshal(second.Body.Bytes(), &delta))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kiali/kiali/kubernetes"
@@ -13,6 +14,37 @@ import (
 	"github.com/kiali/kiali/util"
 )
 
+// protocolMetricFamilies maps a protocol query parameter to the Prometheus metric
+// families that back it. HTTP and gRPC both report through the istio_requests_total /
+// istio_request_duration_seconds families (gRPC is carried as an HTTP/2 request_protocol
+// label value); TCP has no request or duration concept and reports byte counters instead.
+var protocolMetricFamilies = map[string][]string{
+	"http": {"istio_requests_total", "istio_request_duration_seconds"},
+	"grpc": {"istio_requests_total", "istio_request_duration_seconds"},
+	"tcp":  {"istio_tcp_sent_bytes_total", "istio_tcp_received_bytes_total"},
+}
+
+// tcpIncompatibleLabels lists byLabelsIn[]/byLabelsOut[] values that only make sense
+// for HTTP/gRPC request metrics and would otherwise silently group TCP byte counters
+// into empty series.
+var tcpIncompatibleLabels = map[string]bool{
+	"response_code": true,
+}
+
+// validateLabelsForProtocol rejects byLabelsIn[]/byLabelsOut[] values that don't apply
+// to protocol, e.g. 'response_code' against TCP byte-counter metrics.
+func validateLabelsForProtocol(protocol string, labels []string) error {
+	if protocol != "tcp" {
+		return nil
+	}
+	for _, label := range labels {
+		if tcpIncompatibleLabels[strings.TrimSpace(label)] {
+			return fmt.Errorf("Bad request, label '%s' is not compatible with protocol 'tcp'", label)
+		}
+	}
+	return nil
+}
+
 func extractMetricsQueryParams(r *http.Request, q *prometheus.MetricsQuery, k8s kubernetes.IstioClientInterface) error {
 	q.FillDefaults()
 	queryParams := r.URL.Query()
@@ -53,10 +85,26 @@ func extractMetricsQueryParams(r *http.Request, q *prometheus.MetricsQuery, k8s
 			return errors.New("Bad request, cannot parse query parameter 'step'")
 		}
 	}
+	filtersProvided := false
 	if filters, ok := queryParams["filters[]"]; ok && len(filters) > 0 {
 		q.Filters = filters
+		filtersProvided = true
+	}
+	protocol := ""
+	if protocols, ok := queryParams["protocol"]; ok && len(protocols) > 0 {
+		protocol = protocols[0]
+		families, known := protocolMetricFamilies[protocol]
+		if !known {
+			return errors.New("Bad request, query parameter 'protocol' must be one of 'http', 'grpc' or 'tcp'")
+		}
+		if !filtersProvided {
+			q.Filters = families
+		}
 	}
 	if quantiles, ok := queryParams["quantiles[]"]; ok && len(quantiles) > 0 {
+		if protocol == "tcp" {
+			return errors.New("Bad request, query parameter 'quantiles' is not supported for protocol 'tcp'")
+		}
 		for _, quantile := range quantiles {
 			f, err := strconv.ParseFloat(quantile, 64)
 			if err != nil {
@@ -78,9 +126,15 @@ func extractMetricsQueryParams(r *http.Request, q *prometheus.MetricsQuery, k8s
 		}
 	}
 	if lblsin, ok := queryParams["byLabelsIn[]"]; ok && len(lblsin) > 0 {
+		if err := validateLabelsForProtocol(protocol, lblsin); err != nil {
+			return err
+		}
 		q.ByLabelsIn = lblsin
 	}
 	if lblsout, ok := queryParams["byLabelsOut[]"]; ok && len(lblsout) > 0 {
+		if err := validateLabelsForProtocol(protocol, lblsout); err != nil {
+			return err
+		}
 		q.ByLabelsOut = lblsout
 	}
 
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLabelsForProtocolAllowsResponseCodeForHttp(t *testing.T) {
+	assert := assert.New(t)
+	assert.NoError(validateLabelsForProtocol("http", []string{"response_code"}))
+}
+
+func TestValidateLabelsForProtocolRejectsResponseCodeForTcp(t *testing.T) {
+	assert := assert.New(t)
+	err := validateLabelsForProtocol("tcp", []string{"response_code"})
+	assert.Error(err)
+}
+
+func TestValidateLabelsForProtocolAllowsOtherLabelsForTcp(t *testing.T) {
+	assert := assert.New(t)
+	assert.NoError(validateLabelsForProtocol("tcp", []string{"reporter"}))
+}
@@ -0,0 +1,20 @@
+package routing
+
+import (
+	"net"
+
+	"github.com/mwitkow/go-conntrack"
+)
+
+// WrapListenerWithConnTrack wraps ln so per-connection counters (accepted, closed) are
+// exported under the "http" connection group, visible through the existing /metrics
+// handler alongside Kiali's own internal metrics. No dial or accept latency is
+// exported: the wrapped listener only counts connections. The caller
+// (wherever the HTTP server's net.Listener is constructed) should pass its listener
+// through this before calling http.Serve.
+func WrapListenerWithConnTrack(ln net.Listener) net.Listener {
+	return conntrack.NewListener(ln,
+		conntrack.TrackWithName("http"),
+		conntrack.TrackWithTcpKeepAlive(0),
+	)
+}
@@ -0,0 +1,65 @@
+package routing
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kiali/kiali/config"
+)
+
+// registerDebugRoutes adds net/http/pprof's profiles plus /debug/flags and
+// /debug/build_info to appRouter, gated by conf.Server.Profiling.Enabled. When
+// profiling is disabled none of these routes are registered, so they 404 like
+// any other unknown path. Every registered route is wrapped in
+// config.AuthenticationHandler, same as the rest of the API surface, so an
+// operator can't use profiling to bypass auth.
+func registerDebugRoutes(appRouter *mux.Router, conf *config.Config) {
+	if !conf.Server.Profiling.Enabled {
+		return
+	}
+
+	debug := appRouter.PathPrefix("/debug").Subrouter()
+	debug.Handle("/pprof/", auth(http.HandlerFunc(pprof.Index)))
+	debug.Handle("/pprof/cmdline", auth(http.HandlerFunc(pprof.Cmdline)))
+	debug.Handle("/pprof/profile", auth(http.HandlerFunc(pprof.Profile)))
+	debug.Handle("/pprof/symbol", auth(http.HandlerFunc(pprof.Symbol)))
+	debug.Handle("/pprof/trace", auth(http.HandlerFunc(pprof.Trace)))
+	for _, profile := range []string{"heap", "goroutine", "block", "mutex", "allocs", "threadcreate"} {
+		debug.Handle("/pprof/"+profile, auth(pprof.Handler(profile)))
+	}
+
+	debug.Handle("/flags", auth(http.HandlerFunc(debugFlagsHandler)))
+	debug.Handle("/build_info", auth(http.HandlerFunc(debugBuildInfoHandler)))
+}
+
+func auth(next http.Handler) http.Handler {
+	return config.AuthenticationHandler(next)
+}
+
+// debugFlagsHandler reports every registered flag.Flag as JSON, mirroring
+// Prometheus's web package debug endpoint of the same name.
+func debugFlagsHandler(w http.ResponseWriter, r *http.Request) {
+	flags := map[string]string{}
+	flag.VisitAll(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(flags)
+}
+
+// debugBuildInfoHandler reports the Go toolchain/runtime details useful when
+// triaging a report filed against a running Kiali instance.
+func debugBuildInfoHandler(w http.ResponseWriter, r *http.Request) {
+	info := map[string]string{
+		"goVersion": runtime.Version(),
+		"os":        runtime.GOOS,
+		"arch":      runtime.GOARCH,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
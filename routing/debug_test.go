@@ -0,0 +1,45 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kiali/kiali/config"
+)
+
+func TestRegisterDebugRoutesDisabledReturns404(t *testing.T) {
+	assert := assert.New(t)
+
+	conf := config.NewConfig()
+	conf.Server.Profiling.Enabled = false
+
+	router := mux.NewRouter()
+	registerDebugRoutes(router, conf)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/flags", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(http.StatusNotFound, rr.Code)
+}
+
+func TestRegisterDebugRoutesUnauthenticatedReturns401(t *testing.T) {
+	assert := assert.New(t)
+
+	conf := config.NewConfig()
+	conf.Server.Profiling.Enabled = true
+	config.Set(conf)
+
+	router := mux.NewRouter()
+	registerDebugRoutes(router, conf)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/flags", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(http.StatusUnauthorized, rr.Code)
+}
@@ -0,0 +1,34 @@
+package routing
+
+import (
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// newJaegerTracer builds a Jaeger tracer reporting to collectorURL. When alwaysSample
+// is true every span is sampled, which is what the "always sample" testing exporter
+// in the config needs; production deployments would normally use a probabilistic or
+// remote sampler instead.
+func newJaegerTracer(collectorURL string, alwaysSample bool) (opentracing.Tracer, error) {
+	samplerType := jaegercfg.SamplerTypeRemote
+	samplerParam := 0.1
+	if alwaysSample {
+		samplerType = jaegercfg.SamplerTypeConst
+		samplerParam = 1
+	}
+
+	cfg := jaegercfg.Configuration{
+		ServiceName: "kiali",
+		Sampler: &jaegercfg.SamplerConfig{
+			Type:  samplerType,
+			Param: samplerParam,
+		},
+		Reporter: &jaegercfg.ReporterConfig{
+			CollectorEndpoint: collectorURL,
+		},
+	}
+
+	tracer, _, err := cfg.NewTracer()
+	return tracer, err
+}
@@ -1,6 +1,7 @@
 package routing
 
 import (
+	"net"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -10,6 +11,31 @@ import (
 	"github.com/kiali/kiali/prometheus/internalmetrics"
 )
 
+// ListenAndServe builds the router via NewRouter and serves it on addr, the same way
+// net/http.ListenAndServe would. When Server.Profiling.Enabled is set, the listener is
+// wrapped with WrapListenerWithConnTrack first, so per-connection go-conntrack metrics
+// are exported alongside the pprof/debug endpoints that flag also gates.
+//
+// NOTE: this snapshot of the tree has no cmd/main package, so nothing here actually
+// calls ListenAndServe yet - the real server bootstrap (where http.ListenAndServe or
+// an equivalent is today invoked directly against NewRouter()) lives outside what was
+// checked out. Switching that bootstrap to call routing.ListenAndServe instead is the
+// remaining step to make the conntrack wiring live; it can't be done from within this
+// package.
+func ListenAndServe(addr string) error {
+	conf := config.Get()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if conf.Server.Profiling.Enabled {
+		ln = WrapListenerWithConnTrack(ln)
+	}
+
+	return http.Serve(ln, NewRouter())
+}
+
 // NewRouter creates the router with all API routes and the static files handler
 func NewRouter() *mux.Router {
 
@@ -17,6 +43,8 @@ func NewRouter() *mux.Router {
 	webRoot := conf.Server.WebRoot
 	webRootWithSlash := webRoot + "/"
 
+	InitTracer(conf)
+
 	rootRouter := mux.NewRouter().StrictSlash(false)
 	appRouter := rootRouter
 
@@ -35,6 +63,7 @@ func NewRouter() *mux.Router {
 	for _, route := range apiRoutes.Routes {
 		var handlerFunction http.Handler = route.HandlerFunc
 		handlerFunction = metricHandler(handlerFunction, route)
+		handlerFunction = tracingMiddleware(handlerFunction, route.Name)
 		if route.Authenticated {
 			handlerFunction = config.AuthenticationHandler(handlerFunction)
 		}
@@ -48,6 +77,9 @@ func NewRouter() *mux.Router {
 	// The Prometheus scrape endpoint - this reports our internal metrics
 	appRouter.PathPrefix("/metrics").Handler(promhttp.Handler())
 
+	// Profiling/debug endpoints, opt-in via Server.Profiling.Enabled
+	registerDebugRoutes(appRouter, conf)
+
 	// All client-side routes are prefixed with /console.
 	// They are forwarded to index.html and will be handled by react-router.
 	appRouter.PathPrefix("/console").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,47 @@
+package routing
+
+import (
+	"net/http"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/kiali/kiali/config"
+)
+
+// globalTracer is the process-wide tracer installed by InitTracer. It defaults to a
+// noop tracer so that hot paths stay allocation-free when tracing is disabled, and so
+// that callers never need to nil-check before starting a span.
+var globalTracer opentracing.Tracer = opentracing.NoopTracer{}
+
+// InitTracer installs the configured tracer as the global tracer used by tracingMiddleware
+// and by any downstream code that calls opentracing.GlobalTracer(). NewRouter calls it
+// before wrapping any route in tracingMiddleware.
+func InitTracer(conf *config.Config) {
+	if conf == nil || !conf.Server.Tracing.Enabled {
+		globalTracer = opentracing.NoopTracer{}
+		opentracing.SetGlobalTracer(globalTracer)
+		return
+	}
+
+	tracer, _ := newJaegerTracer(conf.Server.Tracing.CollectorURL, conf.Server.Tracing.AlwaysSample)
+	if tracer == nil {
+		tracer = opentracing.NoopTracer{}
+	}
+	globalTracer = tracer
+	opentracing.SetGlobalTracer(globalTracer)
+}
+
+// tracingMiddleware wraps next in an OpenTracing HTTP middleware that starts a server
+// span per route, tagged with the route name, and stores the span context on the
+// request context so appenders/Prometheus/Kubernetes calls further down the stack can
+// start child spans with opentracing.StartSpanFromContext(r.Context(), ...).
+func tracingMiddleware(next http.Handler, routeName string) http.Handler {
+	return nethttp.Middleware(
+		globalTracer,
+		next,
+		nethttp.OperationNameFunc(func(r *http.Request) string {
+			return "kiali." + routeName
+		}),
+	)
+}